@@ -0,0 +1,133 @@
+package markdownrenderer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const ABBREVIATIONREGEX = "^\\*\\[([^\\]]+)\\]:(.*)$"
+
+var abbreviationPattern = regexp.MustCompile(ABBREVIATIONREGEX)
+
+// ExtractAbbreviations collects the "*[HTML]: Hyper Text Markup Language"
+// definitions from all blocks but code. Definition lines are removed, and
+// blocks left empty are dropped.
+func ExtractAbbreviations(blocks []string) ([]string, map[string]string) {
+	sources := make([]Source, 0, len(blocks))
+	for _, b := range blocks {
+		sources = append(sources, NewSource(b))
+	}
+	sources, abbreviations := extractAbbreviations(sources)
+	cleanBlocks := make([]string, 0, len(sources))
+	for _, s := range sources {
+		cleanBlocks = append(cleanBlocks, s.Text)
+	}
+
+	return cleanBlocks, abbreviations
+}
+
+func extractAbbreviations(blocks []Source) ([]Source, map[string]string) {
+	abbreviations := map[string]string{}
+	cleanBlocks := make([]Source, 0, len(blocks))
+	for _, b := range blocks {
+		if isCode(b.Text) {
+			cleanBlocks = append(cleanBlocks, b)
+			continue
+		}
+		lines := []Source{}
+		for _, l := range b.Lines() {
+			found := abbreviationPattern.FindStringSubmatch(strings.TrimSpace(l.Text))
+			if found == nil {
+				lines = append(lines, l)
+				continue
+			}
+			abbreviations[strings.TrimSpace(found[1])] = strings.TrimSpace(found[2])
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		cleanBlocks = append(cleanBlocks, joinSources(lines, "\n"))
+	}
+
+	return cleanBlocks, abbreviations
+}
+
+// Abbreviate wraps every whole-word occurrence of the abbreviations found in
+// plain text. Code, links and images are left untouched.
+func Abbreviate(nodes []Node, abbreviations map[string]string) []Node {
+	if len(abbreviations) == 0 {
+		return nodes
+	}
+	words := abbreviationWords(abbreviations)
+
+	return mapInlines(nodes, func(n Node) []Node {
+		if text, isP := n.(Plain); isP {
+			return nodesOf(abbreviateText(NewSource(string(text)), words, abbreviations))
+		}
+		return []Node{n}
+	})
+}
+
+// abbreviationWords returns the abbreviations longest first, so that
+// "HTML5" is preferred over "HTML"
+func abbreviationWords(abbreviations map[string]string) []string {
+	words := make([]string, 0, len(abbreviations))
+	for w := range abbreviations {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		return len(words[i]) > len(words[j])
+	})
+	return words
+}
+
+func abbreviateText(source Source, words []string, abbreviations map[string]string) []*Element {
+	text := source.Text
+	elements := []*Element{}
+	last := 0
+	for i := 0; i < len(text); {
+		word := abbreviationAt(text, i, words)
+		if len(word) == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		if i > last {
+			elements = append(elements, plainElement(source.Slice(last, i)))
+		}
+		elements = append(elements, source.Slice(i, i+len(word)).Element(Abbreviation{Text: word, Title: abbreviations[word]}))
+		i += len(word)
+		last = i
+	}
+	if last < len(text) {
+		elements = append(elements, plainElement(source.Slice(last, len(text))))
+	}
+
+	return elements
+}
+
+// abbreviationAt returns the abbreviation starting at offset as a whole
+// word, or an empty string
+func abbreviationAt(text string, offset int, words []string) string {
+	if before, _ := utf8.DecodeLastRuneInString(text[:offset]); offset > 0 && isWordRune(before) {
+		return ""
+	}
+	for _, w := range words {
+		if !strings.HasPrefix(text[offset:], w) {
+			continue
+		}
+		after, _ := utf8.DecodeRuneInString(text[offset+len(w):])
+		if offset+len(w) < len(text) && isWordRune(after) {
+			continue
+		}
+		return w
+	}
+	return ""
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,113 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtractAbbreviations(t *testing.T) {
+	tests := []struct {
+		name                  string
+		input                 []string
+		expectedBlocks        []string
+		expectedAbbreviations map[string]string
+	}{
+		{
+			name:                  "no definitions",
+			input:                 []string{"hello", "world"},
+			expectedBlocks:        []string{"hello", "world"},
+			expectedAbbreviations: map[string]string{},
+		},
+		{
+			name:                  "definition block",
+			input:                 []string{"HTML rocks", "*[HTML]: Hyper Text Markup Language\n*[CSS]:  Cascading Style Sheets"},
+			expectedBlocks:        []string{"HTML rocks"},
+			expectedAbbreviations: map[string]string{"HTML": "Hyper Text Markup Language", "CSS": "Cascading Style Sheets"},
+		},
+		{
+			name:                  "definition inside block",
+			input:                 []string{"HTML rocks\n*[HTML]: Hyper Text Markup Language"},
+			expectedBlocks:        []string{"HTML rocks"},
+			expectedAbbreviations: map[string]string{"HTML": "Hyper Text Markup Language"},
+		},
+		{
+			name:                  "code blocks are kept",
+			input:                 []string{"```\n*[HTML]: not a definition\n```"},
+			expectedBlocks:        []string{"```\n*[HTML]: not a definition\n```"},
+			expectedAbbreviations: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBlocks, gotAbbreviations := ExtractAbbreviations(tt.input)
+			if diff := cmp.Diff(gotBlocks, tt.expectedBlocks); diff != "" {
+				t.Errorf("ExtractAbbreviations(%q) blocks\n  Diff:     %s", tt.input, diff)
+			}
+			if diff := cmp.Diff(gotAbbreviations, tt.expectedAbbreviations); diff != "" {
+				t.Errorf("ExtractAbbreviations(%q) abbreviations\n  Diff:     %s", tt.input, diff)
+			}
+		})
+	}
+}
+
+func TestAbbreviate(t *testing.T) {
+	abbreviations := map[string]string{"HTML": "Hyper Text Markup Language", "HTML5": "HTML version 5", "W3C": "World Wide Web Consortium"}
+	html := Abbreviation{Text: "HTML", Title: "Hyper Text Markup Language"}
+
+	tests := []struct {
+		name     string
+		input    []Node
+		expected []Node
+	}{
+		{
+			name:     "paragraph",
+			input:    []Node{Paragraph{Content: []Node{Plain("The HTML spec")}}},
+			expected: []Node{Paragraph{Content: []Node{Plain("The "), html, Plain(" spec")}}},
+		},
+		{
+			name:     "whole words only",
+			input:    []Node{Paragraph{Content: []Node{Plain("HTMLish XHTML HTML_x")}}},
+			expected: []Node{Paragraph{Content: []Node{Plain("HTMLish XHTML HTML_x")}}},
+		},
+		{
+			name:     "longest abbreviation wins",
+			input:    []Node{Paragraph{Content: []Node{Plain("HTML5 and HTML.")}}},
+			expected: []Node{Paragraph{Content: []Node{Abbreviation{Text: "HTML5", Title: "HTML version 5"}, Plain(" and "), html, Plain(".")}}},
+		},
+		{
+			name:     "header",
+			input:    []Node{Header{Content: []Node{Plain("About HTML")}, Level: 2}},
+			expected: []Node{Header{Content: []Node{Plain("About "), html}, Level: 2}},
+		},
+		{
+			name:     "list items",
+			input:    []Node{UnorderedList{UnorderedItem{Plain("W3C")}}},
+			expected: []Node{UnorderedList{UnorderedItem{Abbreviation{Text: "W3C", Title: "World Wide Web Consortium"}}}},
+		},
+		{
+			name:     "code and links untouched",
+			input:    []Node{Paragraph{Content: []Node{InlineCode("HTML"), Hyperlink{Content: []Node{Plain("HTML")}, Link: "HTML"}}}, Code("HTML")},
+			expected: []Node{Paragraph{Content: []Node{InlineCode("HTML"), Hyperlink{Content: []Node{Plain("HTML")}, Link: "HTML"}}}, Code("HTML")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Abbreviate(tt.input, abbreviations)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("Abbreviate(%v)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestAbbreviationsToHTML(t *testing.T) {
+	input := "Use HTML and `HTML`.\n\n*[HTML]: Hyper Text 'Markup' Language"
+	expected := "<div><p>Use <abbr title='Hyper Text &#39;Markup&#39; Language'>HTML</abbr> and <code>HTML</code>.</p></div>"
+	got := MarkdownToHTML(input).HTMLRender()
+	if got != expected {
+		t.Errorf("MarkdownToHTML(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+	}
+}
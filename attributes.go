@@ -0,0 +1,238 @@
+package markdownrenderer
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ATTRIBUTESREGEX    = "\\{(?:\\s*(?:[#.][\\w:-]+|[\\w:-]+=(?:\"(?:[^\"\\\\]|\\\\.)*\"|'[^']*'|[^\\s\"'}]*)))+\\s*\\}"
+	ATTRIBUTENAMEREGEX = "^[A-Za-z_:][\\w:.-]*$"
+)
+
+var attributesPattern = regexp.MustCompile("^" + ATTRIBUTESREGEX + "$")
+var trailingAttributesPattern = regexp.MustCompile("\\s*(" + ATTRIBUTESREGEX + ")\\s*$")
+var attributeNamePattern = regexp.MustCompile(ATTRIBUTENAMEREGEX)
+
+// quoteEscaper and quoteUnescaper handle the backslash escapes of double
+// quoted values
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
+var quoteUnescaper = strings.NewReplacer("\\\\", "\\", "\\\"", "\"")
+
+// Attributes holds the HTML attributes attached to a node. Classes are kept
+// space separated under the "class" key.
+type Attributes map[string]string
+
+// ParseAttributes parses an attribute list such as
+// {#id .class key=value key="quoted value"}. Double quoted values may escape
+// quotes and backslashes with a backslash.
+func ParseAttributes(list string) (Attributes, bool) {
+	list = strings.TrimSpace(list)
+	if !attributesPattern.MatchString(list) {
+		return nil, false
+	}
+	attributes := Attributes{}
+	for _, token := range splitAttributes(list[1 : len(list)-1]) {
+		switch {
+		case strings.HasPrefix(token, "#"):
+			attributes["id"] = token[1:]
+		case strings.HasPrefix(token, "."):
+			attributes.AddClass(token[1:])
+		default:
+			key, value, _ := strings.Cut(token, "=")
+			switch {
+			case len(value) >= 2 && value[0] == '"':
+				value = quoteUnescaper.Replace(value[1 : len(value)-1])
+			case len(value) >= 2 && value[0] == '\'':
+				value = value[1 : len(value)-1]
+			}
+			attributes[key] = value
+		}
+	}
+
+	return attributes, true
+}
+
+// splitAttributes splits on whitespace outside of quoted values
+func splitAttributes(list string) []string {
+	tokens := []string{}
+	token := new(strings.Builder)
+	var quote rune
+	isEscaped := false
+	for _, r := range list {
+		switch {
+		case quote != 0:
+			token.WriteRune(r)
+			switch {
+			case isEscaped:
+				isEscaped = false
+			case r == '\\' && quote == '"':
+				isEscaped = true
+			case r == quote:
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			token.WriteRune(r)
+			quote = r
+		case r == ' ' || r == '\t':
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+// trailingAttributes splits a trailing attribute list off text
+func trailingAttributes(text string) (string, Attributes) {
+	loc := trailingAttributesPattern.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return text, nil
+	}
+	attributes, _ := ParseAttributes(text[loc[2]:loc[3]])
+	return text[:loc[0]], attributes
+}
+
+// mergeAttributes adds the attributes of other that are not already set,
+// joining classes
+func mergeAttributes(a, other Attributes) Attributes {
+	if a == nil {
+		a = Attributes{}
+	}
+	for k, v := range other {
+		if k == "class" {
+			for _, c := range strings.Fields(v) {
+				a.AddClass(c)
+			}
+		} else if _, isS := a[k]; !isS {
+			a[k] = v
+		}
+	}
+	return a
+}
+
+// Clone returns a copy of the attributes, which is never nil
+func (a Attributes) Clone() Attributes {
+	clone := make(Attributes, len(a))
+	for k, v := range a {
+		clone[k] = v
+	}
+	return clone
+}
+
+func (a Attributes) HasClass(class string) bool {
+	for _, c := range strings.Fields(a["class"]) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Attributes) AddClass(class string) {
+	for _, c := range strings.Fields(a["class"]) {
+		if c == class {
+			return
+		}
+	}
+	a["class"] = strings.TrimSpace(a["class"] + " " + class)
+}
+
+func (a Attributes) RemoveClass(class string) {
+	classes := []string{}
+	for _, c := range strings.Fields(a["class"]) {
+		if c != class {
+			classes = append(classes, c)
+		}
+	}
+	if len(classes) == 0 {
+		delete(a, "class")
+		return
+	}
+	a["class"] = strings.Join(classes, " ")
+}
+
+// withClasses returns a copy with the classes a renderer always sets ahead
+// of the user's ones
+func (a Attributes) withClasses(classes ...string) Attributes {
+	return mergeAttributes(Attributes{"class": strings.Join(classes, " ")}, a)
+}
+
+// without returns a copy without the attributes a renderer sets itself
+func (a Attributes) without(keys ...string) Attributes {
+	clone := a.Clone()
+	for _, k := range keys {
+		delete(clone, k)
+	}
+	return clone
+}
+
+// HTMLRender renders the attributes with a leading space, id and class
+// first and the others sorted by name. Values are escaped and attributes
+// with invalid names are dropped.
+func (a Attributes) HTMLRender() string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		if k == "id" || k == "class" || !attributeNamePattern.MatchString(k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if _, isC := a["class"]; isC {
+		keys = append([]string{"class"}, keys...)
+	}
+	if _, isI := a["id"]; isI {
+		keys = append([]string{"id"}, keys...)
+	}
+
+	builder := new(strings.Builder)
+	for _, k := range keys {
+		builder.WriteString(" " + k + "='" + html.EscapeString(a[k]) + "'")
+	}
+	return builder.String()
+}
+
+// MarkdownRender renders the attributes as an attribute list, such as
+// {#id .class key=value}, or as nothing when there are none. Values that
+// cannot go bare are quoted, in single quotes when they hold only double
+// quotes and in double quotes, escaped, otherwise.
+func (a Attributes) MarkdownRender() string {
+	items := []string{}
+	if id, isI := a["id"]; isI && len(id) > 0 {
+		items = append(items, "#"+id)
+	}
+	for _, c := range strings.Fields(a["class"]) {
+		items = append(items, "."+c)
+	}
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		if k != "id" && k != "class" && attributeNamePattern.MatchString(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := a[k]
+		switch {
+		case len(value) > 0 && !strings.ContainsAny(value, " \t\n\"'}"):
+			items = append(items, k+"="+value)
+		case strings.Contains(value, "\"") && !strings.Contains(value, "'"):
+			items = append(items, k+"='"+value+"'")
+		default:
+			items = append(items, k+"=\""+quoteEscaper.Replace(value)+"\"")
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(items, " ") + "}"
+}
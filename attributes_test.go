@@ -0,0 +1,150 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseAttributes(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      Attributes
+		expectedIsAtt bool
+	}{
+		{name: "id", input: "{#intro}", expected: Attributes{"id": "intro"}, expectedIsAtt: true},
+		{name: "classes", input: "{.note .wide}", expected: Attributes{"class": "note wide"}, expectedIsAtt: true},
+		{name: "key value", input: "{width=300}", expected: Attributes{"width": "300"}, expectedIsAtt: true},
+		{name: "quoted values", input: `{title="a title" alt='x y'}`, expected: Attributes{"title": "a title", "alt": "x y"}, expectedIsAtt: true},
+		{name: "escaped quotes", input: `{title="say \"it's\" \\ \n"}`, expected: Attributes{"title": `say "it's" \ \n`}, expectedIsAtt: true},
+		{name: "mixed", input: "{ #id .class data-x=1 }", expected: Attributes{"id": "id", "class": "class", "data-x": "1"}, expectedIsAtt: true},
+		{name: "duplicate class", input: "{.a .a}", expected: Attributes{"class": "a"}, expectedIsAtt: true},
+		{name: "empty", input: "{}", expected: nil, expectedIsAtt: false},
+		{name: "plain words", input: "{not attributes}", expected: nil, expectedIsAtt: false},
+		{name: "no braces", input: "#id", expected: nil, expectedIsAtt: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isAtt := ParseAttributes(tt.input)
+			if isAtt != tt.expectedIsAtt {
+				t.Errorf("ParseAttributes(%q) recognised = %v, expected %v", tt.input, isAtt, tt.expectedIsAtt)
+			}
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("ParseAttributes(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestAttributesHTMLRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Attributes
+		expected string
+	}{
+		{name: "nil", input: nil, expected: ""},
+		{name: "id and class first", input: Attributes{"width": "3", "class": "a b", "alt": "x", "id": "i"}, expected: " id='i' class='a b' alt='x' width='3'"},
+		{name: "escaped values", input: Attributes{"title": `<'a' & "b">`}, expected: " title='&lt;&#39;a&#39; &amp; &#34;b&#34;&gt;'"},
+		{name: "invalid names dropped", input: Attributes{"a b": "x", "><": "y", "ok": "z"}, expected: " ok='z'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("Attributes.HTMLRender() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAttributesMarkdownRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Attributes
+		expected string
+	}{
+		{name: "nil", input: nil, expected: ""},
+		{name: "bare values", input: Attributes{"width": "3", "class": "a b", "id": "i"}, expected: "{#i .a .b width=3}"},
+		{name: "spaces", input: Attributes{"title": "a b"}, expected: `{title="a b"}`},
+		{name: "double quotes", input: Attributes{"title": `say "hi"`}, expected: `{title='say "hi"'}`},
+		{name: "both quotes", input: Attributes{"title": `it's "x"`}, expected: `{title="it's \"x\""}`},
+		{name: "backslash", input: Attributes{"title": `a\ 'b'`}, expected: `{title="a\\ 'b'"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.MarkdownRender()
+			if got != tt.expected {
+				t.Errorf("Attributes.MarkdownRender() = %q, expected %q", got, tt.expected)
+			}
+			if len(tt.input) == 0 {
+				return
+			}
+			parsed, _ := ParseAttributes(got)
+			if diff := cmp.Diff(parsed, tt.input); diff != "" {
+				t.Errorf("ParseAttributes(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", got, parsed, tt.input, diff)
+			}
+		})
+	}
+}
+
+func TestAttributesClasses(t *testing.T) {
+	attributes := Attributes{"id": "x", "class": "a b"}
+	attributes.AddClass("c")
+	attributes.RemoveClass("a")
+	if !attributes.HasClass("b") || !attributes.HasClass("c") || attributes.HasClass("a") {
+		t.Errorf("Attributes classes = %q, expected \"b c\"", attributes["class"])
+	}
+	attributes.RemoveClass("b")
+	attributes.RemoveClass("c")
+	if diff := cmp.Diff(attributes, Attributes{"id": "x"}); diff != "" {
+		t.Errorf("Attributes.RemoveClass() left an empty class\n  Diff:     %s", diff)
+	}
+}
+
+func TestAttributesToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "header",
+			input:    "# Title {#top .main}",
+			expected: "<div><h1 id='top' class='main'>Title</h1></div>",
+		},
+		{
+			name:     "paragraph",
+			input:    "{.note}\nSome text",
+			expected: "<div><p class='note'>Some text</p></div>",
+		},
+		{
+			name:     "image",
+			input:    "![img](x.png){width=300}",
+			expected: "<div><p><img src='x.png' width='300'>img</img></p></div>",
+		},
+		{
+			name:     "link",
+			input:    "[site](a.com){.external}",
+			expected: "<div><p><a href='a.com' class='external'>site</a></p></div>",
+		},
+		{
+			name:     "header with leading and trailing attributes",
+			input:    "{.a}\n## Title {#t .b}",
+			expected: "<div><h2 id='t' class='b a'>Title</h2></div>",
+		},
+		{
+			name:     "braces in text are kept",
+			input:    "a {b} c",
+			expected: "<div><p>a {b} c</p></div>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkdownToHTML(tt.input).HTMLRender()
+			if got != tt.expected {
+				t.Errorf("MarkdownToHTML(%q)\n  got:      %q\n  expected: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
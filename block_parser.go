@@ -2,54 +2,152 @@ package markdownrenderer
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 )
 
 const (
-	BLOCKDELIMITER   = "\n\n"
-	HEADERPREFIX     = "#"
-	BREAKDELIMITER   = "---"
-	CODEDELIMITER    = "```"
-	QUOTEPREFIX1     = "> "
-	QUOTEPREFIX2     = "  "
-	QUOTEPREFIX3     = "\t"
-	UNORDEREDPREFIX1 = "* "
-	UNORDEREDPREFIX2 = "- "
-	ORDEREDPREFIX    = "1. "
+	BLOCKDELIMITER    = "\n\n"
+	HEADERPREFIX      = "#"
+	BREAKDELIMITER    = "---"
+	CODEDELIMITER     = "```"
+	QUOTEPREFIX1      = "> "
+	QUOTEPREFIX2      = "  "
+	QUOTEPREFIX3      = "\t"
+	UNORDEREDPREFIX1  = "* "
+	UNORDEREDPREFIX2  = "- "
+	ORDEREDPREFIX     = "1. "
+	DEFINITIONPREFIX  = ": "
+	DEFINITIONINDENT1 = "  "
+	DEFINITIONINDENT2 = "\t"
+	ADMONITIONFENCE   = ":::"
+	ADMONITIONPREFIX  = "> [!"
+	ADMONITIONSUFFIX  = "]"
+	ADMONITIONQUOTE   = ">"
+	TABLEDELIMITER    = "|"
 )
 
+// tableDelimiterPattern matches the cells of the row separating the header
+// of a table from its rows. Alignment colons are accepted, and ignored.
+var tableDelimiterPattern = regexp.MustCompile("^:?-+:?$")
+
+// AdmonitionKinds lists the admonition types recognised by BlockParser, in
+// lower case. Blocks using any other type fall back to quotes or paragraphs.
+var AdmonitionKinds = []string{"note", "tip", "important", "warning", "caution"}
+
 func MarkdownToBlocks(markdown string) []string {
-	blocks := strings.Split(markdown, BLOCKDELIMITER)
-	cleanBlocks := make([]string, 0, len(blocks))
-	for _, b := range blocks {
-		cleanBlock := strings.TrimSpace(b)
-		if len(cleanBlock) == 0 {
+	blocks := []string{}
+	for _, b := range currentParser().blocks(NewSource(markdown)) {
+		blocks = append(blocks, b.Text)
+	}
+	return blocks
+}
+
+func (p *Parser) blocks(markdown Source) []Source {
+	cleanBlocks := []Source{}
+	// where the last block starts, for definitions to take the indented
+	// blocks that follow them
+	last := 0
+	for start := 0; start <= len(markdown.Text); {
+		end := strings.Index(markdown.Text[start:], BLOCKDELIMITER)
+		if end < 0 {
+			end = len(markdown.Text)
+		} else {
+			end += start
+		}
+		cleanBlock := markdown.Slice(start, end).TrimSpace()
+		switch {
+		case len(cleanBlock.Text) == 0:
+		case len(cleanBlocks) > 0 && p.continuesDefinition(cleanBlocks[len(cleanBlocks)-1].Text, markdown.Text[start:end]):
+			cleanBlocks[len(cleanBlocks)-1] = markdown.Slice(last, end).TrimSpace()
+		default:
+			cleanBlocks = append(cleanBlocks, cleanBlock)
+			last = start
+		}
+		start = end + len(BLOCKDELIMITER)
+	}
+
+	return p.mergeAdmonitionBlocks(cleanBlocks)
+}
+
+// fenced admonitions may contain blank lines, so the blocks between an
+// opening and its closing fence are joined back together. Fences are only
+// counted outside of code, and blocks are left apart when the fence they
+// open is never closed.
+func (p *Parser) mergeAdmonitionBlocks(blocks []Source) []Source {
+	mergedBlocks := make([]Source, 0, len(blocks))
+	for i := 0; i < len(blocks); i++ {
+		end := i
+		depth := p.fenceDepth(blocks[i].Text, 0)
+		for j := i + 1; j < len(blocks) && depth > 0; j++ {
+			depth = p.fenceDepth(blocks[j].Text, depth)
+			if depth == 0 {
+				end = j
+			}
+		}
+		mergedBlocks = append(mergedBlocks, joinSources(blocks[i:end+1], BLOCKDELIMITER))
+		i = end
+	}
+
+	return mergedBlocks
+}
+
+// fenceDepth returns how many fenced admonitions are still open after
+// block, given how many were open before it
+func (p *Parser) fenceDepth(block string, depth int) int {
+	inCode := false
+	for _, l := range strings.Split(block, "\n") {
+		l = strings.TrimSpace(l)
+		switch {
+		case strings.HasPrefix(l, CODEDELIMITER):
+			// code opened and closed on the same line leaves no fence open
+			isInline := len(l) >= 2*len(CODEDELIMITER) && strings.HasSuffix(l, CODEDELIMITER)
+			if !isInline {
+				inCode = !inCode
+			}
+		case inCode:
 			continue
+		case l == ADMONITIONFENCE:
+			depth = max(depth-1, 0)
+		default:
+			if _, _, isA := p.admonitionHeader(l); isA && strings.HasPrefix(l, ADMONITIONFENCE) {
+				depth++
+			}
 		}
-		cleanBlocks = append(cleanBlocks, cleanBlock)
 	}
 
-	return cleanBlocks
+	return depth
 }
 
 func BlockParser(block string) Node {
-	if level, isH := isHeader(block); isH {
-		return headerify(block, level)
-	} else if isBreak(block) {
-		return Break(true)
-	} else if isCode(block) {
-		return codeify(block)
-	} else if del, isQ := isQuote(block); isQ {
-		return quoteify(block, del)
-	} else if isOrderedList(block) {
-		return olistify(block)
-	} else if isUnorderedList(block) {
-		return ulistify(block)
-	} else if isTable(block) {
-		return tableify(block)
-	} else {
-		return Paragraph(LineParser(block))
+	return currentParser().ParseBlock(NewSource(block)).Build()
+}
+
+// ParseBlock parses a block, as split by blank lines, with the first of the
+// block rules recognising it
+func (p *Parser) ParseBlock(block Source) *Element {
+	if attributes, rest, isA := leadingAttributes(block); isA {
+		element := p.ParseBlock(rest)
+		element.Node = attributify(element.Node, attributes)
+		element.Range.Start = block.Range().Start
+		return element
+	}
+	for _, rule := range p.blockRules {
+		if !rule.triggered(block.Text) {
+			continue
+		}
+		if element, isE := rule.Parse(p, block); isE {
+			return element
+		}
 	}
+	paragraph := block.Element(Paragraph{}, p.ParseLine(block)...)
+	if p.enabled(EXTFIGURES) {
+		if figure, isF := p.figurify(paragraph, block); isF {
+			return figure
+		}
+	}
+	return paragraph
 }
 
 func isHeader(block string) (int, bool) {
@@ -104,6 +202,60 @@ func isQuote(block string) (string, bool) {
 	return delimiter, true
 }
 
+func (p *Parser) isDisplayMath(block string) (MathDelimiter, bool) {
+	block, _ = trailingAttributes(block)
+	for _, d := range p.options.DisplayMathDelimiters {
+		if len(block) > len(d.Open)+len(d.Close) && strings.HasPrefix(block, d.Open) && strings.HasSuffix(block, d.Close) {
+			return d, true
+		}
+	}
+	return MathDelimiter{}, false
+}
+
+func (p *Parser) isAdmonition(block string) bool {
+	lines := strings.Split(block, "\n")
+	if _, _, isA := p.admonitionHeader(lines[0]); !isA {
+		return false
+	}
+	if strings.HasPrefix(lines[0], ADMONITIONFENCE) {
+		return len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == ADMONITIONFENCE
+	}
+	for _, l := range lines {
+		if !strings.HasPrefix(l, ADMONITIONQUOTE) {
+			return false
+		}
+	}
+	return true
+}
+
+// admonitionHeader parses either "> [!KIND] title" or ":::kind title". The
+// title ends the line, trailing spaces aside.
+func (p *Parser) admonitionHeader(line string) (string, string, bool) {
+	var kind, title string
+	if strings.HasPrefix(line, ADMONITIONPREFIX) {
+		rest := strings.TrimPrefix(line, ADMONITIONPREFIX)
+		end := strings.Index(rest, ADMONITIONSUFFIX)
+		if end < 0 {
+			return "", "", false
+		}
+		kind = rest[:end]
+		title = strings.TrimSpace(rest[end+len(ADMONITIONSUFFIX):])
+	} else if strings.HasPrefix(line, ADMONITIONFENCE) {
+		rest := strings.TrimSpace(strings.TrimPrefix(line, ADMONITIONFENCE))
+		kind, title, _ = strings.Cut(rest, " ")
+		title = strings.TrimSpace(title)
+	} else {
+		return "", "", false
+	}
+	kind = strings.ToLower(kind)
+	for _, k := range p.options.AdmonitionKinds {
+		if kind == k {
+			return kind, title, true
+		}
+	}
+	return "", "", false
+}
+
 func isUnorderedList(block string) bool {
 	lines := strings.Split(block, "\n")
 	if len(lines) == 0 {
@@ -134,84 +286,308 @@ func isOrderedList(block string) bool {
 	return true
 }
 
-// TODO: to implement
+// isTable tells whether block is a pipe table: a header row, then a row of
+// dashes with as many cells, such as | --- | :-: |, then any rows
 func isTable(block string) bool {
-	return false
+	lines := strings.Split(block, "\n")
+	if len(lines) < 2 || !strings.Contains(lines[0], TABLEDELIMITER) || !strings.Contains(lines[1], TABLEDELIMITER) {
+		return false
+	}
+	delimiters := tableCells(NewSource(lines[1]))
+	if len(delimiters) != len(tableCells(NewSource(lines[0]))) {
+		return false
+	}
+	for _, d := range delimiters {
+		if !tableDelimiterPattern.MatchString(d.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableCells splits a row of a table on the pipes that are not escaped,
+// dropping those that start and end it
+func tableCells(row Source) []Source {
+	row = row.TrimSpace()
+	start, end := 0, len(row.Text)
+	if strings.HasPrefix(row.Text, TABLEDELIMITER) {
+		start++
+	}
+	if end > start && strings.HasSuffix(row.Text, TABLEDELIMITER) && !strings.HasSuffix(row.Text, "\\"+TABLEDELIMITER) {
+		end--
+	}
+	cells := []Source{}
+	cellStart := start
+	for i := start; i < end; i++ {
+		switch row.Text[i] {
+		case '\\':
+			i++
+		case TABLEDELIMITER[0]:
+			cells = append(cells, row.Slice(cellStart, i).TrimSpace())
+			cellStart = i + 1
+		}
+	}
+	return append(cells, row.Slice(cellStart, end).TrimSpace())
 }
 
-func headerify(block string, level int) Header {
+func isDefinitionList(block string) bool {
+	lines := strings.Split(block, "\n")
+	if len(lines) < 2 || strings.HasPrefix(lines[0], DEFINITIONPREFIX) {
+		return false
+	}
+	// every group of terms must be followed by at least one definition, and
+	// indented and blank lines are only allowed as continuation of a
+	// definition
+	pendingTerm := false
+	inDescription := false
+	for _, l := range lines {
+		switch {
+		case strings.HasPrefix(l, DEFINITIONPREFIX):
+			pendingTerm = false
+			inDescription = true
+		case inDescription && (isDefinitionContinuation(l) || len(strings.TrimSpace(l)) == 0):
+			continue
+		case len(strings.TrimSpace(l)) == 0 || isDefinitionContinuation(l):
+			return false
+		default:
+			pendingTerm = true
+			inDescription = false
+		}
+	}
+	return !pendingTerm
+}
+
+// continuesDefinition reports whether block, indented, is one more
+// paragraph of the last definition of the definition list before it
+func (p *Parser) continuesDefinition(before, block string) bool {
+	block = strings.TrimLeft(block, "\n")
+	return p.hasBlockRule("definition_list") && isDefinitionContinuation(block) && isDefinitionList(before)
+}
+
+func isDefinitionContinuation(line string) bool {
+	return strings.HasPrefix(line, DEFINITIONINDENT1) || strings.HasPrefix(line, DEFINITIONINDENT2)
+}
+
+// leadingAttributes splits a block starting with an attribute list line,
+// such as {.note}, from the block it applies to
+func leadingAttributes(block Source) (Attributes, Source, bool) {
+	firstLine, _, isM := strings.Cut(block.Text, "\n")
+	rest := block.Slice(min(len(firstLine)+1, len(block.Text)), len(block.Text)).TrimSpace()
+	if !isM || len(rest.Text) == 0 {
+		return nil, Source{}, false
+	}
+	attributes, isA := ParseAttributes(firstLine)
+	if !isA {
+		return nil, Source{}, false
+	}
+	return attributes, rest, true
+}
+
+// attributify attaches attributes to paragraphs, headers, figures, tables
+// and equations; other blocks are returned unchanged
+func attributify(node Node, attributes Attributes) Node {
+	switch v := node.(type) {
+	case Paragraph:
+		v.Attributes = mergeAttributes(v.Attributes, attributes)
+		return v
+	case Header:
+		v.Attributes = mergeAttributes(v.Attributes, attributes)
+		return v
+	case Figure:
+		v.Attributes = mergeAttributes(v.Attributes, attributes)
+		return v
+	case Table:
+		v.Attributes = mergeAttributes(v.Attributes, attributes)
+		return v
+	case DisplayMath:
+		v.Attributes = mergeAttributes(v.Attributes, attributes)
+		return v
+	default:
+		return node
+	}
+}
+
+func (p *Parser) headerify(block Source, level int) *Element {
 	prefix := ""
 	for i := 0; i < level; i++ {
 		prefix += HEADERPREFIX
 	}
 	prefix += " "
-	content := strings.TrimPrefix(block, prefix)
+	content := block.TrimPrefix(prefix)
+	text, attributes := trailingAttributes(content.Text)
+	content = content.Slice(0, len(text))
+	header := Header{Level: level, Attributes: attributes}
 
-	return Header{LineParser(content), level}
+	return block.Element(header, p.ParseLine(content)...)
 }
 
 func codeify(block string) Code {
 	return Code(strings.Trim(block, CODEDELIMITER))
 }
 
-func quoteify(block, delimiter string) Quote {
-	lines := strings.Split(block, "\n")
-	if len(lines) == 0 {
-		return nil
+func mathify(block string, delimiter MathDelimiter) DisplayMath {
+	formula, attributes := trailingAttributes(block)
+	formula = strings.TrimPrefix(formula, delimiter.Open)
+	formula = strings.TrimSuffix(formula, delimiter.Close)
+
+	return DisplayMath{Text: strings.TrimSpace(formula), Attributes: attributes}
+}
+
+func (p *Parser) quoteify(block Source, delimiter string) *Element {
+	newLines := []Source{}
+	for _, l := range block.Lines() {
+		newLines = append(newLines, l.TrimPrefix(delimiter))
 	}
-	newLines := []string{}
+	content := joinSources(newLines, "\n")
 
-	for _, l := range lines {
-		newLine := strings.TrimPrefix(l, delimiter)
-		newLines = append(newLines, newLine)
+	return block.Element(Quote(nil), p.ParseLine(content)...)
+}
+
+func (p *Parser) admonitionify(block Source) *Element {
+	lines := block.Lines()
+	kind, title, _ := p.admonitionHeader(lines[0].Text)
+	var contentLines []Source
+	if strings.HasPrefix(lines[0].Text, ADMONITIONFENCE) {
+		contentLines = lines[1 : len(lines)-1]
+	} else {
+		for _, l := range lines[1:] {
+			newLine := l.TrimPrefix(QUOTEPREFIX1)
+			if newLine.Text == l.Text {
+				newLine = l.TrimPrefix(ADMONITIONQUOTE)
+			}
+			contentLines = append(contentLines, newLine)
+		}
 	}
-	content := strings.Join(newLines, "\n")
 
-	return Quote(LineParser(content))
+	children := []*Element{}
+	if len(title) > 0 {
+		end := len(strings.TrimRightFunc(lines[0].Text, unicode.IsSpace))
+		titleSource := lines[0].Slice(end-len(title), end)
+		children = append(children, titleSource.Element(AdmonitionTitle(nil), p.ParseLine(titleSource)...))
+	}
+	for _, b := range p.blocks(joinSources(contentLines, "\n")) {
+		children = append(children, p.ParseBlock(b))
+	}
+
+	return newElement(Admonition{Type: kind}, block.Range(), children)
 }
 
 // TODO: subtasks to implement
-func ulistify(block string) UnorderedList {
-	lines := strings.Split(block, "\n")
-	if len(lines) == 0 {
-		return nil
-	}
-	newLines := []UnorderedItem{}
-	for _, l := range lines {
-		newLine := strings.TrimPrefix(l, UNORDEREDPREFIX1)
-		if newLine == l {
-			newLine = strings.TrimPrefix(l, UNORDEREDPREFIX2)
+func (p *Parser) ulistify(block Source) *Element {
+	items := []*Element{}
+	for _, l := range block.Lines() {
+		newLine := l.TrimPrefix(UNORDEREDPREFIX1)
+		if newLine.Text == l.Text {
+			newLine = l.TrimPrefix(UNORDEREDPREFIX2)
 		}
-		newItem := UnorderedItem(LineParser(newLine))
-		newLines = append(newLines, newItem)
+		items = append(items, l.Element(UnorderedItem(nil), p.ParseLine(newLine)...))
 	}
 
-	return newLines
+	return block.Element(UnorderedList(nil), items...)
 }
 
 // TODO: subtasks to implement
-func olistify(block string) OrderedList {
-	lines := strings.Split(block, "\n")
-	if len(lines) == 0 {
-		return nil
-	}
-	newLines := []OrderedItem{}
+func (p *Parser) olistify(block Source) *Element {
+	items := []*Element{}
 	counter := 1
-	for _, l := range lines {
+	for _, l := range block.Lines() {
 		countedPrefix := fmt.Sprintf("%d. ", counter)
-		newLine := strings.TrimPrefix(l, ORDEREDPREFIX)
-		if newLine == l {
-			newLine = strings.TrimPrefix(l, countedPrefix)
+		newLine := l.TrimPrefix(ORDEREDPREFIX)
+		if newLine.Text == l.Text {
+			newLine = l.TrimPrefix(countedPrefix)
 		}
-		newItem := OrderedItem(LineParser(newLine))
-		newLines = append(newLines, newItem)
+		items = append(items, l.Element(OrderedItem(nil), p.ParseLine(newLine)...))
 		counter++
 	}
 
-	return newLines
+	return block.Element(OrderedList(nil), items...)
+}
+
+func (p *Parser) tableify(block Source) *Element {
+	lines := block.Lines()
+	width := len(tableCells(lines[0]))
+	rows := []*Element{p.tableRow(lines[0], TableHeader(nil), width)}
+	for _, l := range lines[2:] {
+		rows = append(rows, p.tableRow(l, TableRow(nil), width))
+	}
+
+	return block.Element(Table{}, rows...)
 }
 
-// TODO: to implement
-func tableify(block string) Table {
-	return Table{}
+// tableRow parses a row of a table width cells wide. Missing cells are left
+// empty and extra ones dropped.
+func (p *Parser) tableRow(line Source, row Node, width int) *Element {
+	cells := []*Element{}
+	for _, cell := range tableCells(line) {
+		if len(cells) == width {
+			break
+		}
+		cell = cell.withText(strings.ReplaceAll(cell.Text, "\\"+TABLEDELIMITER, TABLEDELIMITER))
+		cells = append(cells, cell.Element(TableItem(nil), p.ParseLine(cell)...))
+	}
+	end := emptyAt(Range{Start: line.Range().End})
+	for len(cells) < width {
+		cells = append(cells, newElement(TableItem(nil), end, nil))
+	}
+
+	return line.Element(row, cells...)
+}
+
+func (p *Parser) definitionify(block Source) *Element {
+	entries := []*Element{}
+	children := []*Element{}
+	description := []Source{}
+	// the line the description starts on, marker included
+	var descriptionLine Source
+	for _, l := range block.Lines() {
+		switch {
+		case strings.HasPrefix(l.Text, DEFINITIONPREFIX):
+			if len(description) > 0 {
+				children = append(children, p.descriptionify(descriptionLine, description))
+			}
+			descriptionLine = l
+			description = []Source{l.TrimPrefix(DEFINITIONPREFIX)}
+		case len(description) > 0 && (isDefinitionContinuation(l.Text) || len(strings.TrimSpace(l.Text)) == 0):
+			newLine := l.TrimPrefix(DEFINITIONINDENT1)
+			if newLine.Text == l.Text {
+				newLine = l.TrimPrefix(DEFINITIONINDENT2)
+			}
+			description = append(description, newLine)
+		default:
+			// a term after a definition starts a new entry
+			if len(description) > 0 {
+				children = append(children, p.descriptionify(descriptionLine, description))
+				entries = append(entries, spanning(DefinitionEntry{}, children...))
+				children = []*Element{}
+				description = []Source{}
+			}
+			children = append(children, l.Element(DefinitionTerm(nil), p.ParseLine(l)...))
+		}
+	}
+	if len(description) > 0 {
+		children = append(children, p.descriptionify(descriptionLine, description))
+	}
+	entries = append(entries, spanning(DefinitionEntry{}, children...))
+
+	return block.Element(DefinitionList(nil), entries...)
+}
+
+// definitions hold block content, split into blocks at blank lines; a
+// single plain paragraph is unwrapped so that simple definitions render
+// tight, like list items. The description spans its lines from the marker
+// on the first one.
+func (p *Parser) descriptionify(first Source, lines []Source) *Element {
+	content := joinSources(lines, "\n")
+	children := []*Element{}
+	for _, b := range p.blocks(content) {
+		children = append(children, p.ParseBlock(b))
+	}
+	r := Range{Start: first.Range().Start, End: content.Range().End}
+	if len(children) == 1 {
+		if _, isP := children[0].Node.(Paragraph); isP {
+			return newElement(DefinitionDescription(nil), r, children[0].Children)
+		}
+	}
+
+	return newElement(DefinitionDescription(nil), r, children)
 }
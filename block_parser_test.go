@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestMarkdownToBlocks(t *testing.T) {
@@ -52,6 +53,41 @@ func TestMarkdownToBlocks(t *testing.T) {
 			input:    "one\n\n\n\n\n\ntwo",
 			expected: []string{"one", "two"},
 		},
+		{
+			name:     "fenced admonition kept together",
+			input:    ":::tip\none\n\ntwo\n:::\n\nafter",
+			expected: []string{":::tip\none\n\ntwo\n:::", "after"},
+		},
+		{
+			name:     "nested fenced admonitions kept together",
+			input:    ":::note\n:::tip\ninner\n\ntext\n:::\n\nouter\n:::",
+			expected: []string{":::note\n:::tip\ninner\n\ntext\n:::\n\nouter\n:::"},
+		},
+		{
+			name:     "unclosed fence left apart",
+			input:    ":::note\n\n# Heading\n\npara",
+			expected: []string{":::note", "# Heading", "para"},
+		},
+		{
+			name:     "prose fence left apart",
+			input:    "::: not a fence\n\n# Heading\n\npara\n\n:::",
+			expected: []string{"::: not a fence", "# Heading", "para", ":::"},
+		},
+		{
+			name:     "unknown kind left apart",
+			input:    ":::danger\none\n\ntwo\n:::",
+			expected: []string{":::danger\none", "two\n:::"},
+		},
+		{
+			name:     "fence inside code left apart",
+			input:    "```\n:::note\n```\n\ntext\n\n:::",
+			expected: []string{"```\n:::note\n```", "text", ":::"},
+		},
+		{
+			name:     "indented paragraph of a definition",
+			input:    "Term\n: one\n\n  two\n\n  three\n\nfour\n\n  five",
+			expected: []string{"Term\n: one\n\n  two\n\n  three", "four", "five"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -444,12 +480,47 @@ func TestBlockParser(t *testing.T) {
 		{
 			name:     "paragraph",
 			input:    "Just some text",
-			expected: Paragraph([]Node{Plain("Just some text")}),
+			expected: Paragraph{Content: []Node{Plain("Just some text")}},
 		},
 		{
 			name:     "paragraph with bold",
 			input:    "Some **bold** text",
-			expected: Paragraph([]Node{Plain("Some "), Bold("bold"), Plain(" text")}),
+			expected: Paragraph{Content: []Node{Plain("Some "), Bold("bold"), Plain(" text")}},
+		},
+		{
+			name:     "header with attributes",
+			input:    "# Hello {#hi .big}",
+			expected: Header{Content: []Node{Plain("Hello")}, Level: 1, Attributes: Attributes{"id": "hi", "class": "big"}},
+		},
+		{
+			name:     "paragraph with attributes",
+			input:    "{.note}\nJust some text",
+			expected: Paragraph{Content: []Node{Plain("Just some text")}, Attributes: Attributes{"class": "note"}},
+		},
+		{
+			name:     "display math",
+			input:    "$$\n\\frac{a_1}{b_2}\n$$",
+			expected: DisplayMath{Text: "\\frac{a_1}{b_2}"},
+		},
+		{
+			name:     "display math with brackets",
+			input:    "\\[x^2\\]",
+			expected: DisplayMath{Text: "x^2"},
+		},
+		{
+			name:     "labelled display math",
+			input:    "$$\n\\frac{1}{2}\n$$ {#eq:half}",
+			expected: DisplayMath{Text: "\\frac{1}{2}", Attributes: Attributes{"id": "eq:half"}},
+		},
+		{
+			name:  "definition list",
+			input: "Term\n: Definition",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Plain("Term")}},
+					Descriptions: []DefinitionDescription{{Plain("Definition")}},
+				},
+			},
 		},
 	}
 
@@ -549,7 +620,7 @@ func TestQuoteify(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := quoteify(tt.input, tt.delimiter)
+			got := currentParser().quoteify(NewSource(tt.input), tt.delimiter).Build()
 			if diff := cmp.Diff(got, tt.expected); diff != "" {
 				t.Errorf("quoteify(%q, %q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, tt.delimiter, got, tt.expected, diff)
 			}
@@ -627,7 +698,7 @@ func TestUlistify(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ulistify(tt.input)
+			got := currentParser().ulistify(NewSource(tt.input)).Build()
 			if diff := cmp.Diff(got, tt.expected); diff != "" {
 				t.Errorf("ulistify(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
 			}
@@ -701,10 +772,313 @@ func TestOlistify(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := olistify(tt.input)
+			got := currentParser().olistify(NewSource(tt.input)).Build()
 			if diff := cmp.Diff(got, tt.expected); diff != "" {
 				t.Errorf("olistify(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
 			}
 		})
 	}
 }
+
+func TestIsDefinitionList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "single term and definition", input: "Term\n: Definition", expected: true},
+		{name: "multiple terms", input: "Term one\nTerm two\n: Definition", expected: true},
+		{name: "multiple definitions", input: "Term\n: First\n: Second", expected: true},
+		{name: "multiple entries", input: "A\n: first\nB\n: second", expected: true},
+		{name: "continuation line", input: "Term\n: Definition\n  continued", expected: true},
+		{name: "single line", input: "Term", expected: false},
+		{name: "starts with definition", input: ": Definition\nTerm", expected: false},
+		{name: "term without definition", input: "A\n: first\nB", expected: false},
+		{name: "plain paragraph", input: "line one\nline two", expected: false},
+		{name: "indented line without definition", input: "Term\n  indented\n: Definition", expected: false},
+		{name: "colon without space", input: "Term\n:Definition", expected: false},
+		{name: "blank line within definition", input: "Term\n: one\n\n  two", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isDefinitionList(tt.input)
+			if got != tt.expected {
+				t.Errorf("isDefinitionList(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefinitionify(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected DefinitionList
+	}{
+		{
+			name:  "single term and definition",
+			input: "Term\n: Definition",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Plain("Term")}},
+					Descriptions: []DefinitionDescription{{Plain("Definition")}},
+				},
+			},
+		},
+		{
+			name:  "multiple terms and definitions",
+			input: "Apple\nPomme\n: A fruit\n: A company",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Plain("Apple")}, {Plain("Pomme")}},
+					Descriptions: []DefinitionDescription{{Plain("A fruit")}, {Plain("A company")}},
+				},
+			},
+		},
+		{
+			name:  "multiple entries",
+			input: "A\n: first\nB\n: second",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Plain("A")}},
+					Descriptions: []DefinitionDescription{{Plain("first")}},
+				},
+				{
+					Terms:        []DefinitionTerm{{Plain("B")}},
+					Descriptions: []DefinitionDescription{{Plain("second")}},
+				},
+			},
+		},
+		{
+			name:  "formatted term",
+			input: "**Term**\n: *Definition*",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Bold("Term")}},
+					Descriptions: []DefinitionDescription{{Italic("Definition")}},
+				},
+			},
+		},
+		{
+			name:  "continuation lines",
+			input: "Term\n: line one\n  line two",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Plain("Term")}},
+					Descriptions: []DefinitionDescription{{Plain("line one\nline two")}},
+				},
+			},
+		},
+		{
+			name:  "block content",
+			input: "Term\n: * one\n  * two",
+			expected: DefinitionList{
+				{
+					Terms: []DefinitionTerm{{Plain("Term")}},
+					Descriptions: []DefinitionDescription{{
+						UnorderedList{
+							UnorderedItem([]Node{Plain("one")}),
+							UnorderedItem([]Node{Plain("two")}),
+						},
+					}},
+				},
+			},
+		},
+		{
+			name:  "paragraphs after a blank line",
+			input: "Term\n: one\n\n  two\nNext\n: three",
+			expected: DefinitionList{
+				{
+					Terms:        []DefinitionTerm{{Plain("Term")}},
+					Descriptions: []DefinitionDescription{{Paragraph{Content: []Node{Plain("one")}}, Paragraph{Content: []Node{Plain("two")}}}},
+				},
+				{
+					Terms:        []DefinitionTerm{{Plain("Next")}},
+					Descriptions: []DefinitionDescription{{Plain("three")}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currentParser().definitionify(NewSource(tt.input)).Build()
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("definitionify(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestIsTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "header and delimiter", input: "| A | B |\n| --- | --- |", expected: true},
+		{name: "with rows", input: "| A | B |\n| --- | --- |\n| 1 | 2 |", expected: true},
+		{name: "without outer pipes", input: "A | B\n--- | :-:\n1 | 2", expected: true},
+		{name: "aligned delimiter", input: "| A |\n| :--- |", expected: true},
+		{name: "single line", input: "| A | B |", expected: false},
+		{name: "missing delimiter", input: "| A | B |\n| 1 | 2 |", expected: false},
+		{name: "delimiter width mismatch", input: "| A | B |\n| --- |", expected: false},
+		{name: "plain paragraph", input: "line one\nline two", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isTable(tt.input)
+			if got != tt.expected {
+				t.Errorf("isTable(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTableify(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Table
+	}{
+		{
+			name:  "header only",
+			input: "| A | B |\n| --- | --- |",
+			expected: Table{
+				Header: TableHeader{{Plain("A")}, {Plain("B")}},
+			},
+		},
+		{
+			name:  "inline content and escaped pipe",
+			input: "| A | *B* |\n| --- | :-: |\n| 1 | a \\| b |",
+			expected: Table{
+				Header: TableHeader{{Plain("A")}, {Italic("B")}},
+				Rows:   []TableRow{{{Plain("1")}, {Plain("a | b")}}},
+			},
+		},
+		{
+			name:  "short and long rows",
+			input: "A | B\n--- | ---\n1\n2 | 3 | 4",
+			expected: Table{
+				Header: TableHeader{{Plain("A")}, {Plain("B")}},
+				Rows:   []TableRow{{{Plain("1")}, {}}, {{Plain("2")}, {Plain("3")}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currentParser().tableify(NewSource(tt.input)).Build()
+			if diff := cmp.Diff(got, tt.expected, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("tableify(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestIsAdmonition(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "github note", input: "> [!NOTE]\n> text", expected: true},
+		{name: "github warning lower case", input: "> [!warning]\n> text", expected: true},
+		{name: "github with empty line", input: "> [!TIP]\n> one\n>\n> two", expected: true},
+		{name: "fenced", input: ":::tip\ntext\n:::", expected: true},
+		{name: "fenced with title", input: ":::warning Be careful\ntext\n:::", expected: true},
+		{name: "unknown kind", input: "> [!FOO]\n> text", expected: false},
+		{name: "unknown fenced kind", input: ":::foo\ntext\n:::", expected: false},
+		{name: "fenced without closing", input: ":::tip\ntext", expected: false},
+		{name: "github with unquoted line", input: "> [!NOTE]\ntext", expected: false},
+		{name: "plain quote", input: "> text", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currentParser().isAdmonition(tt.input)
+			if got != tt.expected {
+				t.Errorf("isAdmonition(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAdmonitionify(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Admonition
+	}{
+		{
+			name:  "github note",
+			input: "> [!NOTE]\n> Some **text**",
+			expected: Admonition{
+				Type:    "note",
+				Content: []Node{Paragraph{Content: []Node{Plain("Some "), Bold("text")}}},
+			},
+		},
+		{
+			name:  "github with title",
+			input: "> [!WARNING] Careful\n> text",
+			expected: Admonition{
+				Type:    "warning",
+				Title:   []Node{Plain("Careful")},
+				Content: []Node{Paragraph{Content: []Node{Plain("text")}}},
+			},
+		},
+		{
+			name:  "github with several blocks",
+			input: "> [!TIP]\n> one\n>\n> * two",
+			expected: Admonition{
+				Type: "tip",
+				Content: []Node{
+					Paragraph{Content: []Node{Plain("one")}},
+					UnorderedList{UnorderedItem([]Node{Plain("two")})},
+				},
+			},
+		},
+		{
+			name:  "fenced with title",
+			input: ":::tip Pro *tip*\n# Heading\n\ntext\n:::",
+			expected: Admonition{
+				Type:  "tip",
+				Title: []Node{Plain("Pro "), Italic("tip")},
+				Content: []Node{
+					Header{Content: []Node{Plain("Heading")}, Level: 1},
+					Paragraph{Content: []Node{Plain("text")}},
+				},
+			},
+		},
+		{
+			name:  "nested fenced",
+			input: ":::note\n:::tip\ninner\n:::\n:::",
+			expected: Admonition{
+				Type: "note",
+				Content: []Node{
+					Admonition{Type: "tip", Content: []Node{Paragraph{Content: []Node{Plain("inner")}}}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currentParser().admonitionify(NewSource(tt.input)).Build()
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("admonitionify(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestAdmonitionKinds(t *testing.T) {
+	defaultKinds := AdmonitionKinds
+	defer func() { AdmonitionKinds = defaultKinds }()
+
+	AdmonitionKinds = []string{"danger"}
+	if !currentParser().isAdmonition(":::danger\ntext\n:::") {
+		t.Errorf("isAdmonition did not recognise configured kind %q", "danger")
+	}
+	if currentParser().isAdmonition(":::note\ntext\n:::") {
+		t.Errorf("isAdmonition recognised kind %q which is no longer configured", "note")
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +12,11 @@ import (
 )
 
 func main() {
+	ast := flag.Bool("ast", false, "print the parsed document as JSON instead of HTML")
+	format := flag.Bool("fmt", false, "print the document as normalised Markdown instead of HTML")
+	width := flag.Int("width", 0, "wrap the normalised Markdown at this many columns, or not at all when 0")
+	flag.Parse()
+
 	file, err := os.Open("index.md")
 	if err != nil {
 		panic(err)
@@ -19,8 +27,26 @@ func main() {
 		panic(err)
 	}
 
-	html := mdr.MarkdownToHTML(string(data))
+	if *ast {
+		encoded, err := mdr.EncodeElementJSON(mdr.Parse(string(data)))
+		if err != nil {
+			panic(err)
+		}
+		indented := new(bytes.Buffer)
+		if err := json.Indent(indented, encoded, "", "  "); err != nil {
+			panic(err)
+		}
+		fmt.Println(indented.String())
+		return
+	}
 
-	fmt.Println(html.HTMLRender())
+	if *format {
+		fmt.Print(mdr.FormatMarkdown(string(data), *width))
+		return
+	}
 
+	if err := mdr.MarkdownToWriter(os.Stdout, string(data)); err != nil {
+		panic(err)
+	}
+	fmt.Println()
 }
@@ -1,12 +1,91 @@
 package markdownrenderer
 
+import "io"
+
 func MarkdownToHTML(content string) HTMLNode {
-	blocks := MarkdownToBlocks(content)
-	htmlNodes := []HTMLNode{}
-	for _, b := range blocks {
-		blockNode := BlockParser(b)
-		htmlNodes = append(htmlNodes, blockNode.ToHTML())
+	return Parse(content).Build().ToHTML()
+}
+
+// MarkdownToWriter writes the HTML of content to w, returning the first
+// error writing to it
+func MarkdownToWriter(w io.Writer, content string) error {
+	return WriteHTML(w, MarkdownToHTML(content))
+}
+
+// RenderMarkdown parses content and renders it with renderer
+func RenderMarkdown(content string, renderer Renderer) string {
+	return renderer.Render(Parse(content))
+}
+
+// Converter parses and renders Markdown in the dialect of its options, so
+// that services sharing the package can each use their own. Converters
+// neither read nor change the package-level variables, and are safe for
+// concurrent use.
+type Converter struct {
+	options  Options
+	parser   *Parser
+	renderer Renderer
+}
+
+// optionsRenderer is implemented by the renderers of the package, which
+// render with the options of the converters using them rather than with the
+// package-level variables
+type optionsRenderer interface {
+	withOptions(options *Options) Renderer
+}
+
+// NewConverter returns a converter of the Legacy dialect changed by options
+func NewConverter(options ...Option) *Converter {
+	c := &Converter{}
+	Legacy(&c.options)
+	for _, option := range options {
+		option(&c.options)
+	}
+	if c.options.Renderer == nil {
+		c.options.Renderer = NewHTMLRenderer()
+	}
+	c.parser = newParser(c.options)
+	c.renderer = c.options.Renderer
+	if renderer, isO := c.renderer.(optionsRenderer); isO {
+		c.renderer = renderer.withOptions(&c.options)
 	}
 
-	return HTMLDiv(htmlNodes)
+	return c
+}
+
+// Options returns the options of the converter
+func (c *Converter) Options() Options {
+	return c.options
+}
+
+// Parse parses markdown and runs the transformers of the converter on it.
+// The error reports dangling cross-references, as Parser.Parse does; the
+// document is complete either way.
+func (c *Converter) Parse(markdown string) (*Element, error) {
+	document, err := c.parser.Parse(markdown)
+	Transform(document, c.options.Transformers...)
+	return document, err
+}
+
+// Render renders a document tree with the renderer of the converter
+func (c *Converter) Render(element *Element) string {
+	return c.renderer.Render(element)
+}
+
+// RenderTo writes a document tree to w with the renderer of the converter,
+// and returns the first error writing to w
+func (c *Converter) RenderTo(w io.Writer, element *Element) error {
+	return c.renderer.RenderTo(w, element)
+}
+
+// Convert parses markdown, transforms it and renders it, reporting the
+// errors of Parse along with the whole output
+func (c *Converter) Convert(markdown string) (string, error) {
+	document, err := c.Parse(markdown)
+	return c.Render(document), err
+}
+
+// FormatMarkdown normalises markdown, wrapping it at width when positive
+func FormatMarkdown(content string, width int) string {
+	return RenderMarkdown(content, NewMarkdownRenderer(width))
 }
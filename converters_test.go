@@ -31,13 +31,18 @@ func TestMarkdownToHTML(t *testing.T) {
 		{
 			name:     "header with bold",
 			input:    "# **Bold** Title",
-			expected: "<div><h1><b>Bold</b> Title</h1></div>",
+			expected: "<div><h1><strong>Bold</strong> Title</h1></div>",
 		},
 		{
 			name:     "break",
 			input:    "---",
 			expected: "<div><br></div>",
 		},
+		{
+			name:     "unclosed admonition fence",
+			input:    "::: not a fence\n\n# Heading\n\npara",
+			expected: "<div><p>::: not a fence</p><h1>Heading</h1><p>para</p></div>",
+		},
 		{
 			name:     "code block",
 			input:    "```\nfmt.Println()\n```",
@@ -66,12 +71,12 @@ func TestMarkdownToHTML(t *testing.T) {
 		{
 			name:     "bold text",
 			input:    "**bold**",
-			expected: "<div><p><b>bold</b></p></div>",
+			expected: "<div><p><strong>bold</strong></p></div>",
 		},
 		{
 			name:     "italic text",
 			input:    "*italic*",
-			expected: "<div><p><i>italic</i></p></div>",
+			expected: "<div><p><em>italic</em></p></div>",
 		},
 		{
 			name:     "inline code",
@@ -80,13 +85,13 @@ func TestMarkdownToHTML(t *testing.T) {
 		},
 		{
 			name:     "crossed text",
-			input:    "~crossed~",
-			expected: "<div><p><strike>crossed</strike></p></div>",
+			input:    "~~crossed~~",
+			expected: "<div><p><del>crossed</del></p></div>",
 		},
 		{
-			name:     "underline text",
-			input:    "-underline-",
-			expected: "<div><p><u>underline</u></p></div>",
+			name:     "hyphenated words",
+			input:    "a well-known-thing",
+			expected: "<div><p>a well-known-thing</p></div>",
 		},
 		{
 			name:     "hyperlink",
@@ -101,7 +106,7 @@ func TestMarkdownToHTML(t *testing.T) {
 		{
 			name:     "paragraph with mixed inline",
 			input:    "hello **bold** and *italic* text",
-			expected: "<div><p>hello <b>bold</b> and <i>italic</i> text</p></div>",
+			expected: "<div><p>hello <strong>bold</strong> and <em>italic</em> text</p></div>",
 		},
 		{
 			name:     "two paragraphs",
@@ -131,23 +136,53 @@ func TestMarkdownToHTML(t *testing.T) {
 		{
 			name:     "link with bold text",
 			input:    "[**bold link**](url.com)",
-			expected: "<div><p><a href='url.com'><b>bold link</b></a></p></div>",
+			expected: "<div><p><a href='url.com'><strong>bold link</strong></a></p></div>",
 		},
 		{
 			name:     "ordered list with formatting",
 			input:    "1. **bold** item\n2. *italic* item",
-			expected: "<div><ol><li><b>bold</b> item</li><li><i>italic</i> item</li></ol></div>",
+			expected: "<div><ol><li><strong>bold</strong> item</li><li><em>italic</em> item</li></ol></div>",
 		},
 		{
 			name:     "quote with formatting",
 			input:    "> **bold** and *italic*",
-			expected: "<div><blockquote><b>bold</b> and <i>italic</i></blockquote></div>",
+			expected: "<div><blockquote><strong>bold</strong> and <em>italic</em></blockquote></div>",
 		},
 		{
 			name:     "three blocks mixed",
 			input:    "# Header\n\nParagraph text\n\n* list item",
 			expected: "<div><h1>Header</h1><p>Paragraph text</p><ul><li>list item</li></ul></div>",
 		},
+		{
+			name:     "definition list",
+			input:    "Markdown\n: A markup language\n: A price reduction",
+			expected: "<div><dl><dt>Markdown</dt><dd>A markup language</dd><dd>A price reduction</dd></dl></div>",
+		},
+		{
+			name:     "definition with indented paragraphs",
+			input:    "Term\n: para one\n\n  para two\n\nafter",
+			expected: "<div><dl><dt>Term</dt><dd><p>para one</p><p>para two</p></dd></dl><p>after</p></div>",
+		},
+		{
+			name:     "github alert",
+			input:    "> [!NOTE]\n> Read this",
+			expected: "<div><div class='admonition note'><p class='admonition-title'>Note</p><p>Read this</p></div></div>",
+		},
+		{
+			name:     "fenced admonition with blank lines",
+			input:    ":::warning Careful\nfirst\n\nsecond\n:::",
+			expected: "<div><div class='admonition warning'><p class='admonition-title'>Careful</p><p>first</p><p>second</p></div></div>",
+		},
+		{
+			name:     "inline math",
+			input:    "where $a_i < b_i$ holds",
+			expected: "<div><p>where <span class='math inline'>\\(a_i &lt; b_i\\)</span> holds</p></div>",
+		},
+		{
+			name:     "display math",
+			input:    "$$\nx_1 + x_2\n$$",
+			expected: "<div><div class='math display'>\\[x_1 + x_2\\]</div></div>",
+		},
 		{
 			name:     "image and link in paragraph",
 			input:    "See ![pic](a.png) and [link](b.com)",
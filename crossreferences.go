@@ -0,0 +1,162 @@
+package markdownrenderer
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	CROSSREFERENCEPREFIX = "@"
+	CROSSREFERENCEREGEX  = "@([A-Za-z]+:[\\w:.-]*\\w)"
+)
+
+// SectionLabel, TableLabel and EquationLabel name headings, tables and
+// equations in the text of cross-references, as FigureLabel does for
+// figures
+var SectionLabel = "Section"
+var TableLabel = "Table"
+var EquationLabel = "Equation"
+
+var crossReferencePattern = regexp.MustCompile(CROSSREFERENCEREGEX)
+
+// DanglingReferenceError reports a cross-reference to a label that no
+// heading, figure, table or equation carries
+type DanglingReferenceError struct {
+	Label string
+}
+
+func (e DanglingReferenceError) Error() string {
+	return "dangling cross-reference " + CROSSREFERENCEPREFIX + e.Label
+}
+
+// CrossReferenceParser extracts references such as @fig:arch. Labels need a
+// "kind:" prefix, so that they are not mistaken for mentions.
+func CrossReferenceParser(line string) []Node {
+	return nodesOf(parseCrossReferences(NewSource(line)))
+}
+
+func parseCrossReferences(line Source) []*Element {
+	elements := []*Element{}
+
+	last := 0
+	for _, match := range crossReferencePattern.FindAllStringSubmatchIndex(line.Text, -1) {
+		start, end := match[0], match[1]
+		if start > 0 && !isReferenceBoundary(line.Text[start-1]) {
+			continue
+		}
+		if start > last {
+			elements = append(elements, plainElement(line.Slice(last, start)))
+		}
+		reference := CrossReference{Label: line.Text[match[2]:match[3]]}
+		elements = append(elements, line.Slice(start, end).Element(reference))
+		last = end
+	}
+	if last < len(line.Text) {
+		elements = append(elements, plainElement(line.Slice(last, len(line.Text))))
+	}
+
+	return elements
+}
+
+// ResolveCrossReferences numbers the labelled headings, figures, tables and
+// equations, then points every cross-reference to its target, as in
+// "Figure 3" or "Section 2.1". References to missing labels are left
+// unresolved and reported in the returned error.
+func ResolveCrossReferences(nodes []Node) ([]Node, error) {
+	root := NewElement(Document(nodes))
+	err := currentParser().resolveCrossReferences(root)
+	return root.Build().Children(), err
+}
+
+func (p *Parser) resolveCrossReferences(root *Element) error {
+	numbering := &crossNumbering{options: &p.options, level: sectionLevel(root.Children), labels: map[string]string{}}
+	numbering.number(root.Children)
+
+	errs := []error{}
+	Walk(root, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+		ref, isR := e.Node.(CrossReference)
+		if !isR {
+			return WALKCONTINUE
+		}
+		name, isL := numbering.labels[ref.Label]
+		if !isL {
+			errs = append(errs, DanglingReferenceError{Label: ref.Label})
+			return WALKSKIPCHILDREN
+		}
+		// the name has no source of its own in the reference
+		e.Children = []*Element{newElement(Plain(name), emptyAt(e.Range), nil)}
+		e.Children[0].Parent = e
+		return WALKSKIPCHILDREN
+	}})
+
+	return errors.Join(errs...)
+}
+
+type crossNumbering struct {
+	options   *Options
+	level     int
+	sections  []int
+	figures   int
+	tables    int
+	equations int
+	labels    map[string]string
+}
+
+// sectionLevel returns the level of the topmost heading, which sections are
+// numbered from
+func sectionLevel(elements []*Element) int {
+	level := 0
+	for _, e := range elements {
+		if header, isH := e.Node.(Header); isH && (level == 0 || header.Level < level) {
+			level = header.Level
+		}
+	}
+	return level
+}
+
+func (c *crossNumbering) number(elements []*Element) {
+	for _, e := range elements {
+		switch v := e.Node.(type) {
+		case Header:
+			depth := max(v.Level-c.level, 0)
+			for len(c.sections) <= depth {
+				c.sections = append(c.sections, 0)
+			}
+			c.sections = c.sections[:depth+1]
+			c.sections[depth]++
+			c.label(v.Attributes, c.options.SectionLabel, sectionNumber(c.sections))
+		case Figure:
+			c.figures++
+			c.label(v.Attributes, c.options.FigureLabel, strconv.Itoa(c.figures))
+		case Table:
+			c.tables++
+			c.label(v.Attributes, c.options.TableLabel, strconv.Itoa(c.tables))
+		case DisplayMath:
+			// only labelled equations are numbered
+			if _, isId := v.Attributes["id"]; isId {
+				c.equations++
+				v.Number = c.equations
+				c.label(v.Attributes, c.options.EquationLabel, strconv.Itoa(v.Number))
+				e.Node = v
+			}
+		case Admonition:
+			c.number(e.Children)
+		}
+	}
+}
+
+func (c *crossNumbering) label(attributes Attributes, name, number string) {
+	if id, isId := attributes["id"]; isId {
+		c.labels[id] = name + " " + number
+	}
+}
+
+func sectionNumber(sections []int) string {
+	parts := make([]string, len(sections))
+	for i, s := range sections {
+		parts[i] = strconv.Itoa(s)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,131 @@
+package markdownrenderer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCrossReferenceParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Node
+	}{
+		{name: "plain", input: "no references", expected: []Node{Plain("no references")}},
+		{name: "reference", input: "see @fig:arch.", expected: []Node{Plain("see "), CrossReference{Label: "fig:arch"}, Plain(".")}},
+		{name: "at start", input: "@sec:intro-2 first", expected: []Node{CrossReference{Label: "sec:intro-2"}, Plain(" first")}},
+		{name: "mention", input: "thanks @user", expected: []Node{Plain("thanks @user")}},
+		{name: "email", input: "me@fig:arch", expected: []Node{Plain("me@fig:arch")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CrossReferenceParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("CrossReferenceParser(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestResolveCrossReferences(t *testing.T) {
+	input := []Node{
+		Header{Content: []Node{Plain("A")}, Level: 2},
+		Header{Content: []Node{Plain("B")}, Level: 2},
+		Header{Content: []Node{Plain("B.1")}, Level: 3, Attributes: Attributes{"id": "sec:b1"}},
+		Figure{Caption: []Node{Plain("one")}},
+		Admonition{Type: "note", Content: []Node{Figure{Caption: []Node{Plain("two")}, Attributes: Attributes{"id": "fig:two"}}}},
+		Table{Attributes: Attributes{"id": "tbl:a"}},
+		DisplayMath{Text: "x"},
+		DisplayMath{Text: "y", Attributes: Attributes{"id": "eq:y"}},
+		Paragraph{Content: []Node{CrossReference{Label: "sec:b1"}, CrossReference{Label: "fig:two"}, CrossReference{Label: "tbl:a"}, CrossReference{Label: "eq:y"}, CrossReference{Label: "tbl:none"}}},
+	}
+	expected := []Node{
+		Header{Content: []Node{Plain("A")}, Level: 2},
+		Header{Content: []Node{Plain("B")}, Level: 2},
+		Header{Content: []Node{Plain("B.1")}, Level: 3, Attributes: Attributes{"id": "sec:b1"}},
+		Figure{Caption: []Node{Plain("one")}},
+		Admonition{Type: "note", Content: []Node{Figure{Caption: []Node{Plain("two")}, Attributes: Attributes{"id": "fig:two"}}}},
+		Table{Attributes: Attributes{"id": "tbl:a"}},
+		DisplayMath{Text: "x"},
+		DisplayMath{Text: "y", Number: 1, Attributes: Attributes{"id": "eq:y"}},
+		Paragraph{Content: []Node{
+			CrossReference{Label: "sec:b1", Content: []Node{Plain("Section 2.1")}},
+			CrossReference{Label: "fig:two", Content: []Node{Plain("Figure 2")}},
+			CrossReference{Label: "tbl:a", Content: []Node{Plain("Table 1")}},
+			CrossReference{Label: "eq:y", Content: []Node{Plain("Equation 1")}},
+			CrossReference{Label: "tbl:none"},
+		}},
+	}
+
+	got, err := ResolveCrossReferences(input)
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("ResolveCrossReferences()\n  got:      %v\n  expected: %v\n  Diff:     %s", got, expected, diff)
+	}
+	var dangling DanglingReferenceError
+	if !errors.As(err, &dangling) || dangling.Label != "tbl:none" {
+		t.Errorf("ResolveCrossReferences() error = %v, expected a dangling reference to tbl:none", err)
+	}
+}
+
+func TestConverterDanglingReferences(t *testing.T) {
+	converter := NewConverter(WithExtensions(EXTCROSSREFERENCES))
+	input := "$$x$$ {#eq:a}\n\nSee @eq:a and @eq:b."
+	expected := "<div><div id='eq:a' class='math display'>\\[x\\]<span class='math-number'>(1)</span></div><p>See <a href='#eq:a' class='cross-reference'>Equation 1</a> and @eq:b.</p></div>"
+	got, err := converter.Convert(input)
+	if got != expected {
+		t.Errorf("Convert(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+	}
+	var dangling DanglingReferenceError
+	if !errors.As(err, &dangling) || dangling.Label != "eq:b" {
+		t.Errorf("Convert(%q) error = %v, expected a dangling reference to eq:b", input, err)
+	}
+	if _, err := converter.Convert("$$x$$ {#eq:a}\n\nSee @eq:a."); err != nil {
+		t.Errorf("Convert() error = %v, expected none", err)
+	}
+}
+
+func TestCrossReferencesToHTML(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions Extension
+		input      string
+		expected   string
+	}{
+		{
+			name:     "disabled",
+			input:    "See @eq:a.",
+			expected: "<div><p>See @eq:a.</p></div>",
+		},
+		{
+			name:       "equation",
+			extensions: EXTCROSSREFERENCES,
+			input:      "$$x^2$$ {#eq:a}\n\nSee @eq:a and @eq:b.",
+			expected:   "<div><div id='eq:a' class='math display'>\\[x^2\\]<span class='math-number'>(1)</span></div><p>See <a href='#eq:a' class='cross-reference'>Equation 1</a> and @eq:b.</p></div>",
+		},
+		{
+			name:       "figure and section",
+			extensions: EXTCROSSREFERENCES | EXTFIGURES,
+			input:      "# Intro {#sec:intro}\n\n![Plan](a.png){#fig:plan}\n\n@fig:plan, in @sec:intro",
+			expected:   "<div><h1 id='sec:intro'>Intro</h1><figure id='fig:plan'><img src='a.png'>Plan</img><figcaption>Plan</figcaption></figure><p><a href='#fig:plan' class='cross-reference'>Figure 1</a>, in <a href='#sec:intro' class='cross-reference'>Section 1</a></p></div>",
+		},
+		{
+			name:       "table",
+			extensions: EXTCROSSREFERENCES,
+			input:      "{#tbl:a}\n| A | B |\n| --- | --- |\n| 1 | 2 |\n\nSee @tbl:a.",
+			expected:   "<div><table id='tbl:a'><thead><tr><th>A</th><th>B</th></tr></thead><tbody><tr><td>1</td><td>2</td></tr></tbody></table><p>See <a href='#tbl:a' class='cross-reference'>Table 1</a>.</p></div>",
+		},
+	}
+
+	defer func() { Extensions = 0 }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Extensions = tt.extensions
+			got := MarkdownToHTML(tt.input).HTMLRender()
+			if got != tt.expected {
+				t.Errorf("MarkdownToHTML(%q)\n  got:      %q\n  expected: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
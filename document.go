@@ -0,0 +1,335 @@
+package markdownrenderer
+
+// NodeKind names the type of a node, independently of its Go type
+type NodeKind string
+
+const (
+	DOCUMENTNODE              NodeKind = "document"
+	PLAINNODE                 NodeKind = "plain"
+	BOLDNODE                  NodeKind = "bold"
+	ITALICNODE                NodeKind = "italic"
+	UNDERLINENODE             NodeKind = "underline"
+	INLINECODENODE            NodeKind = "inline_code"
+	CROSSEDNODE               NodeKind = "crossed"
+	HIGHLIGHTNODE             NodeKind = "highlight"
+	SUPERSCRIPTNODE           NodeKind = "superscript"
+	SUBSCRIPTNODE             NodeKind = "subscript"
+	INSERTEDNODE              NodeKind = "inserted"
+	INLINEMATHNODE            NodeKind = "inline_math"
+	HYPERLINKNODE             NodeKind = "hyperlink"
+	IMAGENODE                 NodeKind = "image"
+	ABBREVIATIONNODE          NodeKind = "abbreviation"
+	EMOJINODE                 NodeKind = "emoji"
+	CROSSREFERENCENODE        NodeKind = "cross_reference"
+	WIKILINKNODE              NodeKind = "wiki_link"
+	HEADERNODE                NodeKind = "header"
+	PARAGRAPHNODE             NodeKind = "paragraph"
+	CODENODE                  NodeKind = "code"
+	DISPLAYMATHNODE           NodeKind = "display_math"
+	QUOTENODE                 NodeKind = "quote"
+	BREAKNODE                 NodeKind = "break"
+	ORDEREDLISTNODE           NodeKind = "ordered_list"
+	UNORDEREDLISTNODE         NodeKind = "unordered_list"
+	ORDEREDITEMNODE           NodeKind = "ordered_item"
+	UNORDEREDITEMNODE         NodeKind = "unordered_item"
+	TABLENODE                 NodeKind = "table"
+	TABLEHEADERNODE           NodeKind = "table_header"
+	TABLEROWNODE              NodeKind = "table_row"
+	TABLECELLNODE             NodeKind = "table_cell"
+	DEFINITIONLISTNODE        NodeKind = "definition_list"
+	DEFINITIONENTRYNODE       NodeKind = "definition_entry"
+	DEFINITIONTERMNODE        NodeKind = "definition_term"
+	DEFINITIONDESCRIPTIONNODE NodeKind = "definition_description"
+	ADMONITIONNODE            NodeKind = "admonition"
+	ADMONITIONTITLENODE       NodeKind = "admonition_title"
+	FIGURENODE                NodeKind = "figure"
+	FIGURECAPTIONNODE         NodeKind = "figure_caption"
+)
+
+// Document is the root of a parsed Markdown document and holds its blocks
+type Document []Node
+
+func (b Document) ToHTML() HTMLNode {
+	return HTMLDiv{Content: markdownToHTML(b)}
+}
+func (b Document) Kind() NodeKind {
+	return DOCUMENTNODE
+}
+func (b Document) Children() []Node {
+	return b
+}
+
+// TreeNode is a node of a parsed tree, which knows its kind, its children
+// and its parent, nil for the root. Elements are the tree nodes of Parse.
+type TreeNode interface {
+	Kind() NodeKind
+	ChildNodes() []TreeNode
+	ParentNode() TreeNode
+}
+
+// Element is a node within a document tree, linked to its parent, to its
+// children and, when parsed, to its source. Nodes are values shared by
+// renderers and tests, so they cannot point to their parent: navigation
+// lives on elements, the TreeNodes wrapping them. The children of Node
+// itself are ignored in favour of Children: Build returns the node with the
+// children of the tree, changes included.
+type Element struct {
+	Node     Node
+	Parent   *Element
+	Children []*Element
+	Range    Range
+}
+
+// Parse parses markdown into a tree rooted at the element of its Document,
+// whose Build returns the Document node. Dangling cross-references are left
+// as text; parse with a Parser or a Converter to have them reported.
+func Parse(markdown string) *Element {
+	root, _ := currentParser().Parse(markdown)
+	return root
+}
+
+// Parse parses markdown into a tree rooted at its Document. Parsers record
+// the source of every element as they build it, so every element holds its
+// range in markdown. With EXTCROSSREFERENCES, the error reports the
+// references to missing labels, which are left as text in the tree.
+func (p *Parser) Parse(markdown string) (*Element, error) {
+	source := NewSource(markdown)
+	blocks, abbreviations := extractAbbreviations(p.blocks(source))
+	if len(abbreviations) > 0 {
+		p = p.withAbbreviations(abbreviations)
+	}
+	children := []*Element{}
+	for _, b := range blocks {
+		children = append(children, p.ParseBlock(b))
+	}
+	root := source.Element(Document(nil), children...)
+	p.resolveFigures(root)
+	var err error
+	if p.enabled(EXTCROSSREFERENCES) {
+		err = p.resolveCrossReferences(root)
+	}
+	root.build()
+
+	return root, err
+}
+
+// NewElement builds the tree of elements rooted at node
+func NewElement(node Node) *Element {
+	element := &Element{Node: node}
+	for _, child := range node.Children() {
+		childElement := NewElement(child)
+		childElement.Parent = element
+		element.Children = append(element.Children, childElement)
+	}
+
+	return element
+}
+
+func (e *Element) Kind() NodeKind {
+	return e.Node.Kind()
+}
+
+// ChildNodes returns the children of the element as tree nodes
+func (e *Element) ChildNodes() []TreeNode {
+	children := make([]TreeNode, 0, len(e.Children))
+	for _, child := range e.Children {
+		children = append(children, child)
+	}
+	return children
+}
+
+// ParentNode returns the parent of the element, or nil for the root
+func (e *Element) ParentNode() TreeNode {
+	if e.Parent == nil {
+		return nil
+	}
+	return e.Parent
+}
+
+// Build returns the node of the element with its children rebuilt from the
+// tree
+func (e *Element) Build() Node {
+	if len(e.Children) == 0 && len(e.Node.Children()) == 0 {
+		return e.Node
+	}
+	children := make([]Node, 0, len(e.Children))
+	for _, child := range e.Children {
+		children = append(children, child.Build())
+	}
+
+	return withChildren(e.Node, children)
+}
+
+// build sets the node of every element of the tree to the one Build
+// returns, once parsers are done changing the tree
+func (e *Element) build() {
+	for _, child := range e.Children {
+		child.build()
+	}
+	if len(e.Children) == 0 && len(e.Node.Children()) == 0 {
+		return
+	}
+	children := make([]Node, 0, len(e.Children))
+	for _, child := range e.Children {
+		children = append(children, child.Node)
+	}
+	e.Node = withChildren(e.Node, children)
+}
+
+// withChildren returns node with its children replaced. Children of the
+// wrong type for node are dropped.
+func withChildren(node Node, children []Node) Node {
+	switch v := node.(type) {
+	case Hyperlink:
+		v.Content = children
+		return v
+	case Image:
+		v.Content = children
+		return v
+	case CrossReference:
+		v.Content = children
+		return v
+	case WikiLink:
+		v.Content = children
+		return v
+	case Header:
+		v.Content = children
+		return v
+	case Paragraph:
+		v.Content = children
+		return v
+	case Quote:
+		return Quote(children)
+	case OrderedList:
+		list := OrderedList{}
+		for _, c := range children {
+			if item, isI := c.(OrderedItem); isI {
+				list = append(list, item)
+			}
+		}
+		return list
+	case UnorderedList:
+		list := UnorderedList{}
+		for _, c := range children {
+			if item, isI := c.(UnorderedItem); isI {
+				list = append(list, item)
+			}
+		}
+		return list
+	case OrderedItem:
+		return OrderedItem(children)
+	case UnorderedItem:
+		return UnorderedItem(children)
+	case DefinitionTerm:
+		return DefinitionTerm(children)
+	case DefinitionDescription:
+		return DefinitionDescription(children)
+	case DefinitionEntry:
+		entry := DefinitionEntry{}
+		for _, c := range children {
+			switch child := c.(type) {
+			case DefinitionTerm:
+				entry.Terms = append(entry.Terms, child)
+			case DefinitionDescription:
+				entry.Descriptions = append(entry.Descriptions, child)
+			}
+		}
+		return entry
+	case DefinitionList:
+		list := DefinitionList{}
+		for _, c := range children {
+			if entry, isE := c.(DefinitionEntry); isE {
+				list = append(list, entry)
+			}
+		}
+		return list
+	case Admonition:
+		v.Title = nil
+		v.Content = nil
+		for _, c := range children {
+			if title, isT := c.(AdmonitionTitle); isT {
+				v.Title = title
+			} else {
+				v.Content = append(v.Content, c)
+			}
+		}
+		return v
+	case AdmonitionTitle:
+		return AdmonitionTitle(children)
+	case Figure:
+		v.Image = Image{}
+		v.Caption = nil
+		for _, c := range children {
+			switch child := c.(type) {
+			case Image:
+				v.Image = child
+			case FigureCaption:
+				v.Caption = child
+			}
+		}
+		return v
+	case FigureCaption:
+		return FigureCaption(children)
+	case Table:
+		v.Header = nil
+		v.Rows = nil
+		for _, c := range children {
+			switch child := c.(type) {
+			case TableHeader:
+				v.Header = child
+			case TableRow:
+				v.Rows = append(v.Rows, child)
+			}
+		}
+		return v
+	case TableHeader:
+		return TableHeader(tableItems(children))
+	case TableRow:
+		return TableRow(tableItems(children))
+	case TableItem:
+		return TableItem(children)
+	case Document:
+		return Document(children)
+	default:
+		return node
+	}
+}
+
+func tableItems(children []Node) []TableItem {
+	items := []TableItem{}
+	for _, c := range children {
+		if item, isI := c.(TableItem); isI {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Replace replaces the node of the element, and its children with those of
+// node. The element keeps its parent, its place among its siblings and its
+// range, while the new children have no source.
+func (e *Element) Replace(node Node) {
+	replacement := NewElement(node)
+	e.Node = node
+	e.Children = replacement.Children
+	for _, child := range e.Children {
+		child.Parent = e
+	}
+}
+
+// Remove removes the element from the children of its parent. Elements are
+// best removed after walking the tree, as removing an element while Walk
+// visits its siblings skips the sibling after it.
+func (e *Element) Remove() {
+	if e.Parent == nil {
+		return
+	}
+	siblings := e.Parent.Children
+	for i, sibling := range siblings {
+		if sibling == e {
+			e.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	e.Parent = nil
+}
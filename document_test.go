@@ -0,0 +1,109 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	input := "# Title\n\nSome [link](a.com) here\n\n- one\n- two"
+	expected := Document{
+		Header{Content: []Node{Plain("Title")}, Level: 1},
+		Paragraph{Content: []Node{Plain("Some "), Hyperlink{Content: []Node{Plain("link")}, Link: "a.com"}, Plain(" here")}},
+		UnorderedList{UnorderedItem{Plain("one")}, UnorderedItem{Plain("two")}},
+	}
+
+	root := Parse(input)
+	if root.Kind() != DOCUMENTNODE || root.Parent != nil {
+		t.Errorf("Parse(%q) root kind = %q, expected a document without parent", input, root.Kind())
+	}
+	if diff := cmp.Diff(root.Build(), Node(expected)); diff != "" {
+		t.Errorf("Parse(%q).Build()\n  got:      %v\n  expected: %v\n  Diff:     %s", input, root.Build(), expected, diff)
+	}
+
+	link := root.Children[1].Children[1]
+	if link.Kind() != HYPERLINKNODE || link.Parent.Kind() != PARAGRAPHNODE || link.Parent.Parent != root {
+		t.Errorf("link element kind = %q, parent = %q, expected a hyperlink in a paragraph of the document", link.Kind(), link.Parent.Kind())
+	}
+	item := root.Children[2].Children[1]
+	if item.Kind() != UNORDEREDITEMNODE || item.Children[0].Node != Plain("two") {
+		t.Errorf("second list item = %v, expected the item \"two\"", item.Node)
+	}
+}
+
+func TestTreeNode(t *testing.T) {
+	input := "# Title\n\n- one\n- **two**"
+	var root TreeNode = Parse(input)
+	if root.ParentNode() != nil {
+		t.Errorf("Parse(%q).ParentNode() = %v, expected nil", input, root.ParentNode())
+	}
+
+	// the kinds of the tree, depth first, each checked against its parent
+	kinds := []NodeKind{}
+	var visit func(node TreeNode)
+	visit = func(node TreeNode) {
+		kinds = append(kinds, node.Kind())
+		for _, child := range node.ChildNodes() {
+			if child.ParentNode() != node {
+				t.Errorf("parent of the %q node = %v, expected the %q node", child.Kind(), child.ParentNode(), node.Kind())
+			}
+			visit(child)
+		}
+	}
+	visit(root)
+	expected := []NodeKind{DOCUMENTNODE, HEADERNODE, PLAINNODE, UNORDEREDLISTNODE, UNORDEREDITEMNODE, PLAINNODE, UNORDEREDITEMNODE, BOLDNODE}
+	if diff := cmp.Diff(kinds, expected); diff != "" {
+		t.Errorf("kinds of Parse(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", input, kinds, expected, diff)
+	}
+}
+
+func TestElementBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Node
+		edit     func(e *Element)
+		expected Node
+	}{
+		{
+			name:     "unchanged",
+			input:    Admonition{Type: "note", Title: AdmonitionTitle{Plain("t")}, Content: []Node{Paragraph{Content: []Node{Plain("p")}}}},
+			edit:     func(e *Element) {},
+			expected: Admonition{Type: "note", Title: AdmonitionTitle{Plain("t")}, Content: []Node{Paragraph{Content: []Node{Plain("p")}}}},
+		},
+		{
+			name:  "replaced child",
+			input: Paragraph{Content: []Node{Plain("a"), Bold("b")}},
+			edit: func(e *Element) {
+				e.Children[1] = NewElement(Italic("c"))
+			},
+			expected: Paragraph{Content: []Node{Plain("a"), Italic("c")}},
+		},
+		{
+			name:  "removed children",
+			input: Figure{Image: Image{Path: "a.png"}, Caption: FigureCaption{Plain("c")}},
+			edit: func(e *Element) {
+				e.Children = e.Children[1:]
+			},
+			expected: Figure{Caption: FigureCaption{Plain("c")}},
+		},
+		{
+			name:  "nested change",
+			input: DefinitionList{{Terms: []DefinitionTerm{{Plain("t")}}, Descriptions: []DefinitionDescription{{Plain("d")}}}},
+			edit: func(e *Element) {
+				e.Children[0].Children[1].Children[0].Node = Plain("e")
+			},
+			expected: DefinitionList{{Terms: []DefinitionTerm{{Plain("t")}}, Descriptions: []DefinitionDescription{{Plain("e")}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			element := NewElement(tt.input)
+			tt.edit(element)
+			got := element.Build()
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("Element.Build()\n  got:      %v\n  expected: %v\n  Diff:     %s", got, tt.expected, diff)
+			}
+		})
+	}
+}
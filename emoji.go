@@ -0,0 +1,112 @@
+package markdownrenderer
+
+import (
+	"regexp"
+	"strings"
+)
+
+//go:generate go run gen_emoji.go
+
+const EMOJIREGEX = ":([a-z0-9_+-]+):"
+
+// EmojiImageURL, when set, renders emoji as images instead of Unicode
+// characters. It is a fmt pattern receiving the shortcode name, for instance
+// "https://example.com/emoji/%s.png".
+var EmojiImageURL = ""
+
+var emojiPattern = regexp.MustCompile(EMOJIREGEX)
+var shortcodePattern = regexp.MustCompile("^" + EMOJIREGEX)
+
+// EmojiParser replaces known shortcodes such as :tada: with Emoji nodes.
+// Unknown shortcodes and shortcodes inside code spans are left as text. It
+// runs after SimpleParser, which leaves shortcodes whole, so that emoji do
+// not split emphasis.
+func EmojiParser(line string) []Node {
+	return nodesOf(parseEmoji(NewSource(line)))
+}
+
+func parseEmoji(line Source) []*Element {
+	elements := []*Element{}
+	spans := codeSpans(line.Text)
+
+	last := 0
+	for _, match := range emojiPattern.FindAllStringSubmatchIndex(line.Text, -1) {
+		start, end := match[0], match[1]
+		if insideSpans(start, spans) {
+			continue
+		}
+		name := line.Text[match[2]:match[3]]
+		character, isE := emojiTable[name]
+		if !isE {
+			continue
+		}
+		if start > last {
+			elements = append(elements, plainElement(line.Slice(last, start)))
+		}
+		elements = append(elements, line.Slice(start, end).Element(Emoji{Name: name, Character: character}))
+		last = end
+	}
+	if last < len(line.Text) {
+		elements = append(elements, plainElement(line.Slice(last, len(line.Text))))
+	}
+
+	return elements
+}
+
+// shortcodeAt returns the length of the known shortcode starting at offset,
+// or 0
+func shortcodeAt(line string, offset int) int {
+	if offset >= len(line) || line[offset] != ':' {
+		return 0
+	}
+	match := shortcodePattern.FindStringSubmatchIndex(line[offset:])
+	if match == nil {
+		return 0
+	}
+	if _, isE := emojiTable[line[offset+match[2]:offset+match[3]]]; !isE {
+		return 0
+	}
+	return match[1]
+}
+
+// emojify renders the known shortcodes within the text of emphasis as their
+// Emoji nodes would, when the emoji rule is on. Emphasis holds text rather
+// than nodes, so its shortcodes are kept until then.
+func (w *HTMLWriter) emojify(text string) string {
+	if !w.options.hasInlineRule("emoji") {
+		return text
+	}
+	return emojiPattern.ReplaceAllStringFunc(text, func(shortcode string) string {
+		name := shortcode[1 : len(shortcode)-1]
+		if character, isE := emojiTable[name]; isE {
+			return htmlString(HTMLEmoji{Name: name, Character: character}, w.options)
+		}
+		return shortcode
+	})
+}
+
+// codeSpans returns the byte ranges of the code spans in line
+func codeSpans(line string) [][2]int {
+	spans := [][2]int{}
+	for i := 0; i < len(line); i++ {
+		if !strings.HasPrefix(line[i:], INLINECODEDELIMITER) {
+			continue
+		}
+		end := strings.Index(line[i+1:], INLINECODEDELIMITER)
+		if end < 0 {
+			break
+		}
+		spans = append(spans, [2]int{i, i + end + 2})
+		i += end + 1
+	}
+	return spans
+}
+
+func insideSpans(offset int, spans [][2]int) bool {
+	for _, s := range spans {
+		if offset >= s[0] && offset < s[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEmojiParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Node
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: []Node{},
+		},
+		{
+			name:     "plain text only",
+			input:    "hello world",
+			expected: []Node{Plain("hello world")},
+		},
+		{
+			name:     "shortcode",
+			input:    "shipped :tada:",
+			expected: []Node{Plain("shipped "), Emoji{Name: "tada", Character: "🎉"}},
+		},
+		{
+			name:     "plus one",
+			input:    ":+1: from me",
+			expected: []Node{Emoji{Name: "+1", Character: "👍"}, Plain(" from me")},
+		},
+		{
+			name:     "underscores are not italic",
+			input:    ":white_check_mark::x:",
+			expected: []Node{Emoji{Name: "white_check_mark", Character: "✅"}, Emoji{Name: "x", Character: "❌"}},
+		},
+		{
+			name:     "unknown shortcode",
+			input:    "a :not_an_emoji: b",
+			expected: []Node{Plain("a :not_an_emoji: b")},
+		},
+		{
+			name:     "time of day",
+			input:    "at 10:30:00",
+			expected: []Node{Plain("at 10:30:00")},
+		},
+		{
+			name:     "inside code span",
+			input:    "`:tada:` :fire:",
+			expected: []Node{Plain("`:tada:` "), Emoji{Name: "fire", Character: "🔥"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EmojiParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("EmojiParser(%q)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestEmojiRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		imageURL string
+		expected string
+	}{
+		{
+			name:     "unicode",
+			expected: "🎉",
+		},
+		{
+			name:     "image",
+			imageURL: "https://example.com/emoji/%s.png",
+			expected: "<img class='emoji' src='https://example.com/emoji/tada.png' alt=':tada:'>",
+		},
+	}
+
+	defer func() { EmojiImageURL = "" }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			EmojiImageURL = tt.imageURL
+			got := HTMLEmoji{Name: "tada", Character: "🎉"}.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("HTMLEmoji.HTMLRender() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEmojiInCode(t *testing.T) {
+	got := MarkdownToHTML(":tada: `:tada:`").HTMLRender()
+	expected := "<div><p>🎉 <code>:tada:</code></p></div>"
+	if got != expected {
+		t.Errorf("MarkdownToHTML() = %q, expected %q", got, expected)
+	}
+}
+
+func TestEmojiInEmphasis(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "bold",
+			input:    "**great :tada: work** done",
+			expected: "<div><p><strong>great 🎉 work</strong> done</p></div>",
+		},
+		{
+			name:     "italic",
+			input:    "*a :+1: b*",
+			expected: "<div><p><em>a 👍 b</em></p></div>",
+		},
+		{
+			name:     "underscores in shortcodes",
+			input:    ":white_check_mark: _done_ :x:",
+			expected: "<div><p>✅ <em>done</em> ❌</p></div>",
+		},
+		{
+			name:     "underscores in shortcodes within emphasis",
+			input:    "_all :white_check_mark: here_",
+			expected: "<div><p><em>all ✅ here</em></p></div>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkdownToHTML(tt.input).HTMLRender()
+			if got != tt.expected {
+				t.Errorf("MarkdownToHTML(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEmojiInEmphasisKeepsShortcode(t *testing.T) {
+	input := "**great :tada: work**"
+	document := Parse(input).Build()
+	expected := Document{Paragraph{Content: []Node{Bold("great :tada: work")}}}
+	if diff := cmp.Diff(document, expected, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("Parse(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", input, document, expected, diff)
+	}
+	if got := RenderMarkdown(input, NewMarkdownRenderer(0)); got != input+"\n" {
+		t.Errorf("RenderMarkdown(%q) = %q, expected %q", input, got, input+"\n")
+	}
+
+	defer func() { EmojiImageURL = "" }()
+	EmojiImageURL = "https://example.com/emoji/%s.png"
+	got := MarkdownToHTML(input).HTMLRender()
+	expectedHTML := "<div><p><strong>great <img class='emoji' src='https://example.com/emoji/tada.png' alt=':tada:'> work</strong></p></div>"
+	if got != expectedHTML {
+		t.Errorf("MarkdownToHTML(%q) = %q, expected %q", input, got, expectedHTML)
+	}
+}
@@ -0,0 +1,18 @@
+package markdownrenderer
+
+// Extension flags enable syntax that is not part of the default dialect.
+// They are combined with a bitwise or and assigned to Extensions.
+type Extension int
+
+const (
+	EXTHIGHLIGHT Extension = 1 << iota
+	EXTSUPERSCRIPT
+	EXTSUBSCRIPT
+	EXTINSERT
+	EXTUNDERLINE
+	EXTTYPOGRAPHER
+	EXTFIGURES
+	EXTCROSSREFERENCES
+)
+
+var Extensions Extension
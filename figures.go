@@ -0,0 +1,138 @@
+package markdownrenderer
+
+import "strconv"
+
+// FigureNumbering prefixes the captions of figures with "Figure N", counting
+// them in document order
+var FigureNumbering = false
+
+// FigureLabel names figures in numbered captions and references
+var FigureLabel = "Figure"
+
+// figurify turns a paragraph holding nothing but an image into a figure,
+// captioned with the image title or, when untitled, its alt text. The id of
+// the image moves to the figure and labels it.
+func (p *Parser) figurify(paragraph *Element, block Source) (*Element, bool) {
+	if len(paragraph.Children) != 1 {
+		return nil, false
+	}
+	imageElement := paragraph.Children[0]
+	image, isI := imageElement.Node.(Image)
+	if !isI {
+		return nil, false
+	}
+	var caption *Element
+	if len(image.Title) > 0 {
+		title := imageTitle(block.within(imageElement.Range)).withText(image.Title)
+		caption = title.Element(FigureCaption(nil), p.ParseLine(title)...)
+	} else {
+		caption = spanning(FigureCaption(nil), cloneElements(imageElement.Children, nil)...)
+	}
+	figure := Figure{Attributes: paragraph.Node.(Paragraph).Attributes}
+	if id, isId := image.Attributes["id"]; isId {
+		image.Attributes = image.Attributes.without("id")
+		figure.Attributes = mergeAttributes(figure.Attributes, Attributes{"id": id})
+		imageElement.Node = image
+	}
+
+	return newElement(figure, paragraph.Range, []*Element{imageElement, caption}), true
+}
+
+// imageTitle returns the source of the title of an image, quotes excluded,
+// or its start when the image was not parsed from the image syntax
+func imageTitle(image Source) Source {
+	groups := imagePattern.FindStringSubmatchIndex(image.Text)
+	if groups == nil || groups[0] != 0 {
+		return image.Slice(0, 0)
+	}
+	destination := image.Slice(groups[4], groups[5])
+	start, end, _ := titleBounds(destination.Text)
+	return destination.Slice(start, end)
+}
+
+// cloneElements copies elements and their descendants. The copies span r
+// when given, and the same source as the originals otherwise.
+func cloneElements(elements []*Element, r *Range) []*Element {
+	clones := make([]*Element, 0, len(elements))
+	for _, e := range elements {
+		clone := &Element{Node: e.Node, Range: e.Range}
+		if r != nil {
+			clone.Range = *r
+		}
+		clone.Children = cloneElements(e.Children, r)
+		for _, child := range clone.Children {
+			child.Parent = clone
+		}
+		clones = append(clones, clone)
+	}
+	return clones
+}
+
+// ResolveFigures numbers the figures when FigureNumbering is set, and fills
+// the empty links to a figure label, as in [](#fig:arch), with its name:
+// "Figure N" when numbered, its caption otherwise.
+func ResolveFigures(nodes []Node) []Node {
+	root := NewElement(Document(nodes))
+	currentParser().resolveFigures(root)
+	return root.Build().Children()
+}
+
+func (p *Parser) resolveFigures(root *Element) {
+	labels := map[string][]*Element{}
+	count := 0
+	p.numberFigures(root.Children, &count, labels)
+	if len(labels) == 0 {
+		return
+	}
+
+	Walk(root, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+		link, isL := e.Node.(Hyperlink)
+		if !isL || !isEmptyContent(e.Children) || len(link.Link) < 2 || link.Link[0] != '#' {
+			return WALKCONTINUE
+		}
+		if name, isF := labels[link.Link[1:]]; isF {
+			// the name has no source of its own in the link
+			r := Range{Start: e.Range.Start, End: e.Range.Start}
+			if len(e.Children) > 0 {
+				r = emptyAt(e.Children[0].Range)
+			}
+			e.Children = cloneElements(name, &r)
+			for _, child := range e.Children {
+				child.Parent = e
+			}
+		}
+		return WALKSKIPCHILDREN
+	}})
+}
+
+func (p *Parser) numberFigures(elements []*Element, count *int, labels map[string][]*Element) {
+	for _, e := range elements {
+		switch v := e.Node.(type) {
+		case Figure:
+			var name []*Element
+			if len(e.Children) > 1 {
+				name = e.Children[1].Children
+			}
+			if p.options.FigureNumbering {
+				*count++
+				v.Number = *count
+				name = []*Element{{Node: Plain(p.options.FigureLabel + " " + strconv.Itoa(v.Number))}}
+			}
+			if id, isId := v.Attributes["id"]; isId {
+				labels[id] = name
+			}
+			e.Node = v
+		case Admonition:
+			p.numberFigures(e.Children, count, labels)
+		}
+	}
+}
+
+func isEmptyContent(elements []*Element) bool {
+	for _, e := range elements {
+		if text, isP := e.Node.(Plain); !isP || len(text) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFigurify(t *testing.T) {
+	image := Image{Content: []Node{Plain("alt")}, Path: "a.png"}
+	tests := []struct {
+		name          string
+		input         Paragraph
+		expected      Figure
+		expectedIsFig bool
+	}{
+		{
+			name:          "alt text caption",
+			input:         Paragraph{Content: []Node{image}},
+			expected:      Figure{Image: image, Caption: []Node{Plain("alt")}},
+			expectedIsFig: true,
+		},
+		{
+			name:          "title caption",
+			input:         Paragraph{Content: []Node{Image{Content: []Node{Plain("alt")}, Path: "a.png", Title: "The *title*"}}},
+			expected:      Figure{Image: Image{Content: []Node{Plain("alt")}, Path: "a.png", Title: "The *title*"}, Caption: []Node{Plain("The "), Italic("title")}},
+			expectedIsFig: true,
+		},
+		{
+			name:          "labelled",
+			input:         Paragraph{Content: []Node{Image{Content: []Node{Plain("alt")}, Path: "a.png", Attributes: Attributes{"id": "fig:a", "width": "3"}}}},
+			expected:      Figure{Image: Image{Content: []Node{Plain("alt")}, Path: "a.png", Attributes: Attributes{"width": "3"}}, Caption: []Node{Plain("alt")}, Attributes: Attributes{"id": "fig:a"}},
+			expectedIsFig: true,
+		},
+		{
+			name:          "image within text",
+			input:         Paragraph{Content: []Node{Plain("see "), image}},
+			expected:      Figure{},
+			expectedIsFig: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			figure, isFig := currentParser().figurify(NewElement(tt.input), NewSource(""))
+			if isFig != tt.expectedIsFig {
+				t.Errorf("figurify(%v) recognised = %v, expected %v", tt.input, isFig, tt.expectedIsFig)
+			}
+			var got Node = Figure{}
+			if isFig {
+				got = figure.Build()
+			}
+			if diff := cmp.Diff(got, Node(tt.expected)); diff != "" {
+				t.Errorf("figurify(%v)\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestFiguresToHTML(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions Extension
+		numbering  bool
+		input      string
+		expected   string
+	}{
+		{
+			name:     "disabled",
+			input:    "![alt](a.png)",
+			expected: "<div><p><img src='a.png'>alt</img></p></div>",
+		},
+		{
+			name:       "alt caption",
+			extensions: EXTFIGURES,
+			input:      "![alt](a.png)",
+			expected:   "<div><figure><img src='a.png'>alt</img><figcaption>alt</figcaption></figure></div>",
+		},
+		{
+			name:       "title caption",
+			extensions: EXTFIGURES,
+			input:      `![alt](a.png "Overview")`,
+			expected:   "<div><figure><img src='a.png' title='Overview'>alt</img><figcaption>Overview</figcaption></figure></div>",
+		},
+		{
+			name:       "numbered with references",
+			extensions: EXTFIGURES,
+			numbering:  true,
+			input:      "See [](#fig:b).\n\n![First](a.png)\n\n![Second](b.png){#fig:b}",
+			expected:   "<div><p>See <a href='#fig:b'>Figure 2</a>.</p><figure><img src='a.png'>First</img><figcaption>Figure 1: First</figcaption></figure><figure id='fig:b'><img src='b.png'>Second</img><figcaption>Figure 2: Second</figcaption></figure></div>",
+		},
+		{
+			name:       "unnumbered reference",
+			extensions: EXTFIGURES,
+			input:      "![Plan](a.png){#plan}\n\nSee [](#plan) or [the plan](#plan).",
+			expected:   "<div><figure id='plan'><img src='a.png'>Plan</img><figcaption>Plan</figcaption></figure><p>See <a href='#plan'>Plan</a> or <a href='#plan'>the plan</a>.</p></div>",
+		},
+	}
+
+	defer func() {
+		Extensions = 0
+		FigureNumbering = false
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Extensions = tt.extensions
+			FigureNumbering = tt.numbering
+			got := MarkdownToHTML(tt.input).HTMLRender()
+			if got != tt.expected {
+				t.Errorf("MarkdownToHTML(%q)\n  got:      %q\n  expected: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+//go:build ignore
+
+// gen_emoji generates emoji_table.go from the emoji database of gemoji, the
+// source of the shortcodes of GitHub. Run it with go generate, or with
+// -input to read a local copy of db/emoji.json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+)
+
+const GEMOJIURL = "https://raw.githubusercontent.com/github/gemoji/master/db/emoji.json"
+
+type gemoji struct {
+	Emoji   string   `json:"emoji"`
+	Aliases []string `json:"aliases"`
+}
+
+func main() {
+	input := flag.String("input", "", "path of db/emoji.json, fetched from gemoji when empty")
+	output := flag.String("output", "emoji_table.go", "path of the generated file")
+	flag.Parse()
+
+	data, err := read(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	emoji := []gemoji{}
+	if err := json.Unmarshal(data, &emoji); err != nil {
+		log.Fatal(err)
+	}
+
+	table := map[string]string{}
+	for _, e := range emoji {
+		for _, alias := range e.Aliases {
+			table[alias] = e.Emoji
+		}
+	}
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	source := new(bytes.Buffer)
+	source.WriteString("// Code generated by gen_emoji.go; DO NOT EDIT.\n\n")
+	source.WriteString("package markdownrenderer\n\n")
+	source.WriteString("// emojiTable maps GitHub emoji shortcodes to their Unicode characters\n")
+	source.WriteString("var emojiTable = map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(source, "\t%q: %q,\n", name, table[name])
+	}
+	source.WriteString("}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, formatted, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func read(path string) ([]byte, error) {
+	if len(path) > 0 {
+		return os.ReadFile(path)
+	}
+	response, err := http.Get(GEMOJIURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", GEMOJIURL, response.Status)
+	}
+	return io.ReadAll(response.Body)
+}
@@ -1,7 +1,11 @@
 package markdownrenderer
 
 import (
+	"bufio"
 	"fmt"
+	"html"
+	"io"
+	"reflect"
 	"strings"
 )
 
@@ -9,120 +13,826 @@ type HTMLNode interface {
 	HTMLRender() string
 }
 
+// htmlWriter is implemented by the HTML nodes that write themselves to an
+// HTMLWriter rather than build strings, which all those of the package do.
+// Their HTMLRender is a wrapper writing to a string builder.
+type htmlWriter interface {
+	RenderHTML(w *HTMLWriter)
+}
+
+// LegacyTags renders bold, italic and crossed text with the presentational
+// <b>, <i> and <strike> tags instead of <strong>, <em> and <del>.
+var LegacyTags = false
+
+// MathConverter, when set, converts the TeX source of math nodes during
+// rendering, for instance with TeXToMathML. Formulas it fails to convert
+// are rendered as escaped source for a client-side renderer.
+var MathConverter func(tex string, display bool) (string, error)
+
+// HTMLWriter is the output HTML nodes write themselves to, rather than build
+// strings, and the render functions of HTMLRenderer write document trees
+// to. It holds the options of the rendering, those of the converter running
+// it or the package-level variables. Writes after the first error writing
+// to the output are dropped, and nodes are no longer written, so rendering
+// stops early.
+type HTMLWriter struct {
+	out interface {
+		io.Writer
+		io.StringWriter
+	}
+	options *Options
+	// renderer renders the elements of document trees, or is nil when
+	// writing HTML nodes alone
+	renderer *HTMLRenderer
+	err      error
+}
+
+func newHTMLWriter(out io.Writer, options *Options) *HTMLWriter {
+	w := &HTMLWriter{options: options}
+	if stringWriter, isS := out.(interface {
+		io.Writer
+		io.StringWriter
+	}); isS {
+		w.out = stringWriter
+	} else {
+		w.out = bytesWriter{out}
+	}
+	return w
+}
+
+// bytesWriter writes strings to writers without a WriteString method
+type bytesWriter struct {
+	io.Writer
+}
+
+func (b bytesWriter) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+func (w *HTMLWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.out.Write(p)
+	w.err = err
+	return n, err
+}
+
+func (w *HTMLWriter) WriteString(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.out.WriteString(s)
+	w.err = err
+	return n, err
+}
+
+// Err returns the first error writing to the output
+func (w *HTMLWriter) Err() error {
+	return w.err
+}
+
+// Options returns the options of the rendering
+func (w *HTMLWriter) Options() Options {
+	return *w.options
+}
+
+// WriteNode writes an HTML node. Nodes from outside the package are written
+// as their HTMLRender returns them.
+func (w *HTMLWriter) WriteNode(node HTMLNode) {
+	if w.err != nil || node == nil {
+		return
+	}
+	if writer, isW := node.(htmlWriter); isW {
+		writer.RenderHTML(w)
+		return
+	}
+	w.WriteString(node.HTMLRender())
+}
+
+func renderString(node htmlWriter) string {
+	options := currentOptions()
+	builder := new(strings.Builder)
+	node.RenderHTML(newHTMLWriter(builder, &options))
+
+	return builder.String()
+}
+
+// htmlString renders node with options
+func htmlString(node HTMLNode, options *Options) string {
+	builder := new(strings.Builder)
+	newHTMLWriter(builder, options).WriteNode(node)
+
+	return builder.String()
+}
+
+// WriteHTML writes the HTML of node to w through a buffer, and returns the
+// first error writing to w
+func WriteHTML(w io.Writer, node HTMLNode) error {
+	options := currentOptions()
+	buffered := bufio.NewWriter(w)
+	output := newHTMLWriter(buffered, &options)
+	output.WriteNode(node)
+	if output.err != nil {
+		return output.err
+	}
+
+	return buffered.Flush()
+}
+
 func htmlRender(nodes []HTMLNode) string {
+	options := currentOptions()
 	builder := new(strings.Builder)
+	writeNodes(newHTMLWriter(builder, &options), nodes)
+
+	return builder.String()
+}
+
+func writeNodes(w *HTMLWriter, nodes []HTMLNode) {
 	for _, h := range nodes {
-		builder.WriteString(h.HTMLRender())
+		w.WriteNode(h)
 	}
+}
 
-	return builder.String()
+func writeElement(w *HTMLWriter, tag string, attributes Attributes, text string) {
+	fmt.Fprintf(w, "<%s%s>%s</%s>", tag, attributes.HTMLRender(), text, tag)
+}
+
+func writeContainer(w *HTMLWriter, tag string, attributes Attributes, content []HTMLNode) {
+	writeTag(w, tag, attributes, func() {
+		writeNodes(w, content)
+	})
+}
+
+func (w *HTMLWriter) math(tex string, display bool) string {
+	if converter := w.options.MathConverter; converter != nil {
+		if converted, err := converter(tex, display); err == nil {
+			return converted
+		}
+	}
+	if display {
+		return fmt.Sprintf("\\[%s\\]", html.EscapeString(tex))
+	}
+	return fmt.Sprintf("\\(%s\\)", html.EscapeString(tex))
+}
+
+// attributesType is the type of the Attributes field of HTML elements
+var attributesType = reflect.TypeOf(Attributes{})
+
+// attributesField returns the Attributes field of the HTML element v, or
+// an invalid value when v is not an element
+func attributesField(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	field := v.FieldByName("Attributes")
+	if !field.IsValid() || field.Type() != attributesType {
+		return reflect.Value{}
+	}
+	return field
+}
+
+// HTMLAttributes returns the attributes of node, and whether it is an
+// element: a struct with an Attributes field, as every HTML node type of the
+// package but HTMLPlain is. Extensions and post-processors use it to
+// decorate nodes without knowing their type, and their own node types are
+// elements by having the field.
+func HTMLAttributes(node HTMLNode) (Attributes, bool) {
+	if node == nil {
+		return nil, false
+	}
+	field := attributesField(reflect.ValueOf(node))
+	if !field.IsValid() {
+		return nil, false
+	}
+	return field.Interface().(Attributes), true
+}
+
+// WithAttributes returns a copy of node holding attributes in place of its
+// own. Nodes that are not elements are returned unchanged.
+func WithAttributes(node HTMLNode, attributes Attributes) HTMLNode {
+	if _, isE := HTMLAttributes(node); !isE {
+		return node
+	}
+	copied := reflect.New(reflect.TypeOf(node)).Elem()
+	copied.Set(reflect.ValueOf(node))
+	attributesField(copied).Set(reflect.ValueOf(attributes))
+	return copied.Interface().(HTMLNode)
+}
+
+// AddClass returns node with class added to its attributes. Nodes that are
+// not elements are returned unchanged.
+func AddClass(node HTMLNode, class string) HTMLNode {
+	attributes, isE := HTMLAttributes(node)
+	if !isE {
+		return node
+	}
+	attributes = attributes.Clone()
+	attributes.AddClass(class)
+	return WithAttributes(node, attributes)
+}
+
+// RemoveClass returns node with class removed from its attributes
+func RemoveClass(node HTMLNode, class string) HTMLNode {
+	attributes, isE := HTMLAttributes(node)
+	if !isE {
+		return node
+	}
+	attributes = attributes.Clone()
+	attributes.RemoveClass(class)
+	return WithAttributes(node, attributes)
+}
+
+// SetAttribute returns node with the attribute key set to value
+func SetAttribute(node HTMLNode, key, value string) HTMLNode {
+	attributes, isE := HTMLAttributes(node)
+	if !isE {
+		return node
+	}
+	attributes = attributes.Clone()
+	attributes[key] = value
+	return WithAttributes(node, attributes)
 }
 
 // Leaves
 
+// HTMLPlain is a text node and, unlike the other nodes, carries no attributes
 type HTMLPlain string
-type HTMLBold string
-type HTMLItalic string
-type HTMLUnderline string
-type HTMLInlineCode string
-type HTMLCrossed string
+type HTMLBold struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLItalic struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLUnderline struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLInlineCode struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLCrossed struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLInlineMath struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLHighlight struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLSuperscript struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLSubscript struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLInserted struct {
+	Text       string
+	Attributes Attributes
+}
 type HTMLHyperlink struct {
-	Content []HTMLNode
-	Link    string
+	Content    []HTMLNode
+	Link       string
+	Attributes Attributes
 }
 type HTMLImage struct {
-	Content []HTMLNode
-	Path    string
+	Content    []HTMLNode
+	Path       string
+	Title      string
+	Attributes Attributes
+}
+type HTMLAbbreviation struct {
+	Text       string
+	Title      string
+	Attributes Attributes
+}
+type HTMLEmoji struct {
+	Name       string
+	Character  string
+	Attributes Attributes
+}
+type HTMLWikiLink struct {
+	Content    []HTMLNode
+	Link       string
+	Exists     bool
+	Attributes Attributes
 }
 
-func (t HTMLPlain) HTMLRender() string {
-	return string(t)
+func (t HTMLPlain) RenderHTML(w *HTMLWriter) {
+	w.WriteString(string(t))
 }
-func (t HTMLBold) HTMLRender() string {
-	return fmt.Sprintf("<b>%s</b>", t)
+func (t HTMLBold) RenderHTML(w *HTMLWriter) {
+	if w.options.LegacyTags {
+		writeElement(w, "b", t.Attributes, w.emojify(t.Text))
+		return
+	}
+	writeElement(w, "strong", t.Attributes, w.emojify(t.Text))
 }
-func (t HTMLItalic) HTMLRender() string {
-	return fmt.Sprintf("<i>%s</i>", t)
+func (t HTMLItalic) RenderHTML(w *HTMLWriter) {
+	if w.options.LegacyTags {
+		writeElement(w, "i", t.Attributes, w.emojify(t.Text))
+		return
+	}
+	writeElement(w, "em", t.Attributes, w.emojify(t.Text))
 }
-func (t HTMLUnderline) HTMLRender() string {
-	return fmt.Sprintf("<u>%s</u>", t)
+func (t HTMLUnderline) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "u", t.Attributes, w.emojify(t.Text))
 }
-func (t HTMLInlineCode) HTMLRender() string {
-	return fmt.Sprintf("<code>%s</code>", t)
+func (t HTMLInlineCode) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "code", t.Attributes, t.Text)
 }
-func (t HTMLCrossed) HTMLRender() string {
-	return fmt.Sprintf("<strike>%s</strike>", t)
+func (t HTMLCrossed) RenderHTML(w *HTMLWriter) {
+	if w.options.LegacyTags {
+		writeElement(w, "strike", t.Attributes, w.emojify(t.Text))
+		return
+	}
+	writeElement(w, "del", t.Attributes, w.emojify(t.Text))
 }
-func (t HTMLHyperlink) HTMLRender() string {
-	return fmt.Sprintf("<a href='%s'>%s</a>", t.Link, htmlRender(t.Content))
+func (t HTMLHighlight) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "mark", t.Attributes, w.emojify(t.Text))
 }
-func (t HTMLImage) HTMLRender() string {
-	return fmt.Sprintf("<img src='%s'>%s</img>", t.Path, htmlRender(t.Content))
+func (t HTMLSuperscript) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "sup", t.Attributes, w.emojify(t.Text))
+}
+func (t HTMLSubscript) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "sub", t.Attributes, w.emojify(t.Text))
+}
+func (t HTMLInserted) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "ins", t.Attributes, w.emojify(t.Text))
+}
+func (t HTMLInlineMath) RenderHTML(w *HTMLWriter) {
+	writeElement(w, "span", t.Attributes.withClasses("math", "inline"), w.math(t.Text, false))
+}
+func (t HTMLHyperlink) RenderHTML(w *HTMLWriter) {
+	fmt.Fprintf(w, "<a href='%s'%s>", html.EscapeString(t.Link), t.Attributes.without("href").HTMLRender())
+	writeNodes(w, t.Content)
+	w.WriteString("</a>")
+}
+func (t HTMLImage) RenderHTML(w *HTMLWriter) {
+	attributes := t.Attributes.without("src")
+	if len(t.Title) > 0 {
+		attributes["title"] = t.Title
+	}
+	fmt.Fprintf(w, "<img src='%s'%s>", html.EscapeString(t.Path), attributes.HTMLRender())
+	writeNodes(w, t.Content)
+	w.WriteString("</img>")
+}
+func (t HTMLAbbreviation) RenderHTML(w *HTMLWriter) {
+	attributes := t.Attributes.without("title")
+	fmt.Fprintf(w, "<abbr title='%s'%s>%s</abbr>", html.EscapeString(t.Title), attributes.HTMLRender(), t.Text)
+}
+func (t HTMLEmoji) RenderHTML(w *HTMLWriter) {
+	if pattern := w.options.EmojiImageURL; len(pattern) > 0 {
+		src := fmt.Sprintf(pattern, t.Name)
+		attributes := t.Attributes.withClasses("emoji").without("src", "alt")
+		fmt.Fprintf(w, "<img%s src='%s' alt=':%s:'>", attributes.HTMLRender(), html.EscapeString(src), t.Name)
+		return
+	}
+	// a bare character has no element to carry the attributes
+	if len(t.Attributes) > 0 {
+		writeElement(w, "span", t.Attributes, t.Character)
+		return
+	}
+	w.WriteString(t.Character)
+}
+func (t HTMLWikiLink) RenderHTML(w *HTMLWriter) {
+	attributes := t.Attributes.without("href")
+	if !t.Exists {
+		attributes = attributes.withClasses("new-page")
+	}
+	fmt.Fprintf(w, "<a href='%s'%s>", html.EscapeString(t.Link), attributes.HTMLRender())
+	writeNodes(w, t.Content)
+	w.WriteString("</a>")
 }
 
 // Containers
 
-type HTMLDiv []HTMLNode
+type HTMLDiv struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
 type HTMLHeader struct {
-	Content []HTMLNode
-	Level   int
+	Content    []HTMLNode
+	Level      int
+	Attributes Attributes
+}
+type HTMLParagraph struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
+type HTMLCode struct {
+	Text       string
+	Attributes Attributes
+}
+type HTMLDisplayMath struct {
+	Text       string
+	Number     int
+	Attributes Attributes
+}
+type HTMLQuote struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
+type HTMLBreak struct {
+	Attributes Attributes
 }
-type HTMLParagraph []HTMLNode
-type HTMLCode string
-type HTMLQuote []HTMLNode
-type HTMLBreak bool
 
-type HTMLListItem []HTMLNode
+type HTMLListItem struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
 type HTMLUnorderedItem HTMLListItem
 type HTMLOrderedItem HTMLListItem
-type HTMLOrderedList []HTMLOrderedItem
-type HTMLUnorderedList []HTMLUnorderedItem
+type HTMLOrderedList struct {
+	Items      []HTMLOrderedItem
+	Attributes Attributes
+}
+type HTMLUnorderedList struct {
+	Items      []HTMLUnorderedItem
+	Attributes Attributes
+}
 
 type HTMLTableItem []HTMLNode
 type HTMLTableHeader []HTMLTableItem
 type HTMLTableRow []HTMLTableItem
 type HTMLTable struct {
-	Header HTMLTableHeader
-	Rows   []HTMLTableRow
+	Header     HTMLTableHeader
+	Rows       []HTMLTableRow
+	Attributes Attributes
+}
+
+type HTMLDefinitionTerm struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
+type HTMLDefinitionDescription struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
+type HTMLDefinitionEntry struct {
+	Terms        []HTMLDefinitionTerm
+	Descriptions []HTMLDefinitionDescription
+}
+type HTMLDefinitionList struct {
+	Entries    []HTMLDefinitionEntry
+	Attributes Attributes
+}
+
+type HTMLAdmonition struct {
+	Type       string
+	Title      []HTMLNode
+	Content    []HTMLNode
+	Attributes Attributes
+}
+
+type HTMLAdmonitionTitle struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
+
+type HTMLFigureCaption struct {
+	Content    []HTMLNode
+	Attributes Attributes
+}
+type HTMLFigure struct {
+	Image      HTMLImage
+	Caption    []HTMLNode
+	Number     int
+	Attributes Attributes
+}
+
+func (b HTMLDiv) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "div", b.Attributes, b.Content)
+}
+func (b HTMLHeader) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, fmt.Sprintf("h%d", b.Level), b.Attributes, b.Content)
+}
+func (b HTMLParagraph) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "p", b.Attributes, b.Content)
+}
+func (b HTMLCode) RenderHTML(w *HTMLWriter) {
+	fmt.Fprintf(w, "<pre%s><code>%s</code></pre>", b.Attributes.HTMLRender(), b.Text)
+}
+func (b HTMLDisplayMath) RenderHTML(w *HTMLWriter) {
+	attributes := b.Attributes.withClasses("math", "display")
+	fmt.Fprintf(w, "<div%s>%s", attributes.HTMLRender(), w.math(b.Text, true))
+	if b.Number > 0 {
+		fmt.Fprintf(w, "<span class='math-number'>(%d)</span>", b.Number)
+	}
+	w.WriteString("</div>")
+}
+func (b HTMLQuote) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "blockquote", b.Attributes, b.Content)
+}
+func (b HTMLBreak) RenderHTML(w *HTMLWriter) {
+	fmt.Fprintf(w, "<br%s>", b.Attributes.HTMLRender())
+}
+func (b HTMLOrderedList) RenderHTML(w *HTMLWriter) {
+	orderedListTemplate(w, b.Attributes, func() {
+		for _, item := range b.Items {
+			item.RenderHTML(w)
+		}
+	})
+}
+func (b HTMLUnorderedList) RenderHTML(w *HTMLWriter) {
+	unorderedListTemplate(w, b.Attributes, func() {
+		for _, item := range b.Items {
+			item.RenderHTML(w)
+		}
+	})
+}
+func (b HTMLOrderedItem) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "li", b.Attributes, b.Content)
 }
+func (b HTMLUnorderedItem) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "li", b.Attributes, b.Content)
+}
+func (b HTMLDefinitionTerm) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "dt", b.Attributes, b.Content)
+}
+func (b HTMLDefinitionDescription) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "dd", b.Attributes, b.Content)
+}
+func (b HTMLDefinitionList) RenderHTML(w *HTMLWriter) {
+	definitionListTemplate(w, b.Attributes, func() {
+		for _, entry := range b.Entries {
+			entry.RenderHTML(w)
+		}
+	})
+}
+func (b HTMLDefinitionEntry) RenderHTML(w *HTMLWriter) {
+	for _, term := range b.Terms {
+		term.RenderHTML(w)
+	}
+	for _, desc := range b.Descriptions {
+		desc.RenderHTML(w)
+	}
+}
+func (b HTMLAdmonition) RenderHTML(w *HTMLWriter) {
+	title := HTMLAdmonitionTitle{Content: b.Title}
+	if len(b.Title) == 0 {
+		title.Content = []HTMLNode{HTMLPlain(admonitionTypeTitle(b.Type))}
+	}
+	admonitionTemplate(w, b.Type, b.Attributes, func() {
+		title.RenderHTML(w)
+	}, func() {
+		writeNodes(w, b.Content)
+	})
+}
+func (b HTMLAdmonitionTitle) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "p", b.Attributes.withClasses("admonition-title"), b.Content)
+}
+func (b HTMLFigure) RenderHTML(w *HTMLWriter) {
+	caption := HTMLFigureCaption{Content: b.Caption}
+	if b.Number > 0 {
+		prefix := HTMLPlain(figureNumber(w.options, b.Number, len(b.Caption) > 0))
+		caption.Content = append([]HTMLNode{prefix}, b.Caption...)
+	}
+	var writeCaption func()
+	if len(caption.Content) > 0 {
+		writeCaption = func() {
+			caption.RenderHTML(w)
+		}
+	}
+	figureTemplate(w, b.Attributes, func() {
+		b.Image.RenderHTML(w)
+	}, writeCaption)
+}
+func (b HTMLFigureCaption) RenderHTML(w *HTMLWriter) {
+	writeContainer(w, "figcaption", b.Attributes, b.Content)
+}
+func (b HTMLTable) RenderHTML(w *HTMLWriter) {
+	tableTemplate(w, b.Attributes, func() {
+		b.Header.RenderHTML(w)
+	}, func() {
+		for _, row := range b.Rows {
+			row.RenderHTML(w)
+		}
+	})
+}
+func (b HTMLTableHeader) RenderHTML(w *HTMLWriter) {
+	tableRowTemplate(w, func() {
+		for _, cell := range b {
+			tableCellTemplate(w, true, func() {
+				writeNodes(w, cell)
+			})
+		}
+	})
+}
+func (b HTMLTableRow) RenderHTML(w *HTMLWriter) {
+	tableRowTemplate(w, func() {
+		for _, cell := range b {
+			cell.RenderHTML(w)
+		}
+	})
+}
+func (b HTMLTableItem) RenderHTML(w *HTMLWriter) {
+	tableCellTemplate(w, false, func() {
+		writeNodes(w, b)
+	})
+}
+
+// Rendering to strings
 
+func (t HTMLPlain) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLBold) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLItalic) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLUnderline) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLInlineCode) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLCrossed) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLHighlight) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLSuperscript) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLSubscript) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLInserted) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLInlineMath) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLHyperlink) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLImage) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLAbbreviation) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLEmoji) HTMLRender() string {
+	return renderString(t)
+}
+func (t HTMLWikiLink) HTMLRender() string {
+	return renderString(t)
+}
 func (b HTMLDiv) HTMLRender() string {
-	return fmt.Sprintf("<div>%s</div>", htmlRender(b))
+	return renderString(b)
 }
 func (b HTMLHeader) HTMLRender() string {
-	return fmt.Sprintf("<h%d>%s</h%d>", b.Level, htmlRender(b.Content), b.Level)
+	return renderString(b)
 }
 func (b HTMLParagraph) HTMLRender() string {
-	return fmt.Sprintf("<p>%s</p>", htmlRender(b))
+	return renderString(b)
 }
 func (b HTMLCode) HTMLRender() string {
-	return fmt.Sprintf("<pre><code>%s</code></pre>", b)
+	return renderString(b)
+}
+func (b HTMLDisplayMath) HTMLRender() string {
+	return renderString(b)
 }
 func (b HTMLQuote) HTMLRender() string {
-	return fmt.Sprintf("<blockquote>%s</blockquote>", htmlRender(b))
+	return renderString(b)
 }
 func (b HTMLBreak) HTMLRender() string {
-	return "<br>"
+	return renderString(b)
 }
 func (b HTMLOrderedList) HTMLRender() string {
-	builder := new(strings.Builder)
-	builder.WriteString("<ol>")
-	for _, item := range b {
-		renderedItem := htmlRender(item)
-		builder.WriteString(fmt.Sprintf("<li>%s</li>", renderedItem))
+	return renderString(b)
+}
+func (b HTMLUnorderedList) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLOrderedItem) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLUnorderedItem) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLDefinitionTerm) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLDefinitionDescription) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLDefinitionList) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLDefinitionEntry) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLAdmonition) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLAdmonitionTitle) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLFigure) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLFigureCaption) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLTable) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLTableHeader) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLTableRow) HTMLRender() string {
+	return renderString(b)
+}
+func (b HTMLTableItem) HTMLRender() string {
+	return renderString(b)
+}
+
+// Templates
+//
+// The elements holding others are written by templates, which the HTML nodes
+// pass functions writing their children, and HTMLRenderer functions
+// rendering those of the tree, so that both render them alike.
+
+func writeTag(w *HTMLWriter, tag string, attributes Attributes, content func()) {
+	fmt.Fprintf(w, "<%s%s>", tag, attributes.HTMLRender())
+	content()
+	fmt.Fprintf(w, "</%s>", tag)
+}
+
+func orderedListTemplate(w *HTMLWriter, attributes Attributes, items func()) {
+	writeTag(w, "ol", attributes, items)
+}
+
+func unorderedListTemplate(w *HTMLWriter, attributes Attributes, items func()) {
+	writeTag(w, "ul", attributes, items)
+}
+
+func definitionListTemplate(w *HTMLWriter, attributes Attributes, entries func()) {
+	writeTag(w, "dl", attributes, entries)
+}
+
+func admonitionTemplate(w *HTMLWriter, kind string, attributes Attributes, title, content func()) {
+	writeTag(w, "div", attributes.withClasses("admonition", kind), func() {
+		title()
+		content()
+	})
+}
+
+// admonitionTypeTitle titles untitled admonitions after their type
+func admonitionTypeTitle(kind string) string {
+	if len(kind) == 0 {
+		return ""
 	}
-	builder.WriteString("</ol>")
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
 
-	return builder.String()
+// figureTemplate writes a figure, with a caption unless caption is nil
+func figureTemplate(w *HTMLWriter, attributes Attributes, image, caption func()) {
+	writeTag(w, "figure", attributes, func() {
+		image()
+		if caption != nil {
+			caption()
+		}
+	})
 }
-func (b HTMLUnorderedList) HTMLRender() string {
-	builder := new(strings.Builder)
-	builder.WriteString("<ul>")
-	for _, item := range b {
-		renderedItem := htmlRender(item)
-		builder.WriteString(fmt.Sprintf("<li>%s</li>", renderedItem))
+
+func tableTemplate(w *HTMLWriter, attributes Attributes, header, rows func()) {
+	writeTag(w, "table", attributes, func() {
+		writeTag(w, "thead", nil, header)
+		writeTag(w, "tbody", nil, rows)
+	})
+}
+
+func tableRowTemplate(w *HTMLWriter, cells func()) {
+	writeTag(w, "tr", nil, cells)
+}
+
+// tableCellTemplate writes a cell of the header of a table, or of its body
+func tableCellTemplate(w *HTMLWriter, header bool, content func()) {
+	if header {
+		writeTag(w, "th", nil, content)
+		return
 	}
-	builder.WriteString("</ul>")
+	writeTag(w, "td", nil, content)
+}
 
-	return builder.String()
+// figureNumber returns the text numbered figures start their caption with
+func figureNumber(options *Options, number int, captioned bool) string {
+	if captioned {
+		return fmt.Sprintf("%s %d: ", options.FigureLabel, number)
+	}
+	return fmt.Sprintf("%s %d", options.FigureLabel, number)
 }
@@ -1,6 +1,12 @@
 package markdownrenderer
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
 
 func TestHTMLPlainRender(t *testing.T) {
 	tests := []struct {
@@ -28,8 +34,8 @@ func TestHTMLBoldRender(t *testing.T) {
 		input    HTMLBold
 		expected string
 	}{
-		{name: "simple", input: HTMLBold("bold"), expected: "<b>bold</b>"},
-		{name: "empty", input: HTMLBold(""), expected: "<b></b>"},
+		{name: "simple", input: HTMLBold{Text: "bold"}, expected: "<strong>bold</strong>"},
+		{name: "empty", input: HTMLBold{Text: ""}, expected: "<strong></strong>"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -47,8 +53,8 @@ func TestHTMLItalicRender(t *testing.T) {
 		input    HTMLItalic
 		expected string
 	}{
-		{name: "simple", input: HTMLItalic("italic"), expected: "<i>italic</i>"},
-		{name: "empty", input: HTMLItalic(""), expected: "<i></i>"},
+		{name: "simple", input: HTMLItalic{Text: "italic"}, expected: "<em>italic</em>"},
+		{name: "empty", input: HTMLItalic{Text: ""}, expected: "<em></em>"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,14 +66,37 @@ func TestHTMLItalicRender(t *testing.T) {
 	}
 }
 
+func TestHTMLLegacyTagsRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLNode
+		expected string
+	}{
+		{name: "bold", input: HTMLBold{Text: "bold"}, expected: "<b>bold</b>"},
+		{name: "italic", input: HTMLItalic{Text: "italic"}, expected: "<i>italic</i>"},
+		{name: "crossed", input: HTMLCrossed{Text: "crossed"}, expected: "<strike>crossed</strike>"},
+	}
+
+	defer func() { LegacyTags = false }()
+	LegacyTags = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("%T.HTMLRender() = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestHTMLUnderlineRender(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    HTMLUnderline
 		expected string
 	}{
-		{name: "simple", input: HTMLUnderline("underline"), expected: "<u>underline</u>"},
-		{name: "empty", input: HTMLUnderline(""), expected: "<u></u>"},
+		{name: "simple", input: HTMLUnderline{Text: "underline"}, expected: "<u>underline</u>"},
+		{name: "empty", input: HTMLUnderline{Text: ""}, expected: "<u></u>"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -85,8 +114,8 @@ func TestHTMLInlineCodeRender(t *testing.T) {
 		input    HTMLInlineCode
 		expected string
 	}{
-		{name: "simple", input: HTMLInlineCode("code"), expected: "<code>code</code>"},
-		{name: "empty", input: HTMLInlineCode(""), expected: "<code></code>"},
+		{name: "simple", input: HTMLInlineCode{Text: "code"}, expected: "<code>code</code>"},
+		{name: "empty", input: HTMLInlineCode{Text: ""}, expected: "<code></code>"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -104,8 +133,8 @@ func TestHTMLCrossedRender(t *testing.T) {
 		input    HTMLCrossed
 		expected string
 	}{
-		{name: "simple", input: HTMLCrossed("crossed"), expected: "<strike>crossed</strike>"},
-		{name: "empty", input: HTMLCrossed(""), expected: "<strike></strike>"},
+		{name: "simple", input: HTMLCrossed{Text: "crossed"}, expected: "<del>crossed</del>"},
+		{name: "empty", input: HTMLCrossed{Text: ""}, expected: "<del></del>"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -117,6 +146,27 @@ func TestHTMLCrossedRender(t *testing.T) {
 	}
 }
 
+func TestHTMLExtensionRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLNode
+		expected string
+	}{
+		{name: "highlight", input: HTMLHighlight{Text: "marked"}, expected: "<mark>marked</mark>"},
+		{name: "superscript", input: HTMLSuperscript{Text: "2"}, expected: "<sup>2</sup>"},
+		{name: "subscript", input: HTMLSubscript{Text: "i"}, expected: "<sub>i</sub>"},
+		{name: "inserted", input: HTMLInserted{Text: "new"}, expected: "<ins>new</ins>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("%T.HTMLRender() = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestHTMLHyperlinkRender(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -130,8 +180,8 @@ func TestHTMLHyperlinkRender(t *testing.T) {
 		},
 		{
 			name:     "bold content",
-			input:    HTMLHyperlink{Content: []HTMLNode{HTMLBold("bold")}, Link: "url.com"},
-			expected: "<a href='url.com'><b>bold</b></a>",
+			input:    HTMLHyperlink{Content: []HTMLNode{HTMLBold{Text: "bold"}}, Link: "url.com"},
+			expected: "<a href='url.com'><strong>bold</strong></a>",
 		},
 		{
 			name:     "empty content",
@@ -189,7 +239,7 @@ func TestHTMLDivRender(t *testing.T) {
 	}{
 		{
 			name:     "single paragraph",
-			input:    HTMLDiv{HTMLParagraph{HTMLPlain("hello")}},
+			input:    HTMLDiv{Content: []HTMLNode{HTMLParagraph{Content: []HTMLNode{HTMLPlain("hello")}}}},
 			expected: "<div><p>hello</p></div>",
 		},
 		{
@@ -199,7 +249,7 @@ func TestHTMLDivRender(t *testing.T) {
 		},
 		{
 			name:     "multiple children",
-			input:    HTMLDiv{HTMLParagraph{HTMLPlain("one")}, HTMLParagraph{HTMLPlain("two")}},
+			input:    HTMLDiv{Content: []HTMLNode{HTMLParagraph{Content: []HTMLNode{HTMLPlain("one")}}, HTMLParagraph{Content: []HTMLNode{HTMLPlain("two")}}}},
 			expected: "<div><p>one</p><p>two</p></div>",
 		},
 	}
@@ -231,8 +281,8 @@ func TestHTMLHeaderRender(t *testing.T) {
 		},
 		{
 			name:     "h2 with bold",
-			input:    HTMLHeader{Content: []HTMLNode{HTMLBold("Bold Title")}, Level: 2},
-			expected: "<h2><b>Bold Title</b></h2>",
+			input:    HTMLHeader{Content: []HTMLNode{HTMLBold{Text: "Bold Title"}}, Level: 2},
+			expected: "<h2><strong>Bold Title</strong></h2>",
 		},
 	}
 	for _, tt := range tests {
@@ -253,13 +303,13 @@ func TestHTMLParagraphRender(t *testing.T) {
 	}{
 		{
 			name:     "plain text",
-			input:    HTMLParagraph{HTMLPlain("hello world")},
+			input:    HTMLParagraph{Content: []HTMLNode{HTMLPlain("hello world")}},
 			expected: "<p>hello world</p>",
 		},
 		{
 			name:     "mixed content",
-			input:    HTMLParagraph{HTMLPlain("hello "), HTMLBold("bold"), HTMLPlain(" world")},
-			expected: "<p>hello <b>bold</b> world</p>",
+			input:    HTMLParagraph{Content: []HTMLNode{HTMLPlain("hello "), HTMLBold{Text: "bold"}, HTMLPlain(" world")}},
+			expected: "<p>hello <strong>bold</strong> world</p>",
 		},
 		{
 			name:     "empty",
@@ -285,17 +335,17 @@ func TestHTMLCodeRender(t *testing.T) {
 	}{
 		{
 			name:     "simple code",
-			input:    HTMLCode("fmt.Println()"),
+			input:    HTMLCode{Text: "fmt.Println()"},
 			expected: "<pre><code>fmt.Println()</code></pre>",
 		},
 		{
 			name:     "empty",
-			input:    HTMLCode(""),
+			input:    HTMLCode{Text: ""},
 			expected: "<pre><code></code></pre>",
 		},
 		{
 			name:     "multiline",
-			input:    HTMLCode("line1\nline2"),
+			input:    HTMLCode{Text: "line1\nline2"},
 			expected: "<pre><code>line1\nline2</code></pre>",
 		},
 	}
@@ -317,13 +367,13 @@ func TestHTMLQuoteRender(t *testing.T) {
 	}{
 		{
 			name:     "plain text",
-			input:    HTMLQuote{HTMLPlain("quoted")},
+			input:    HTMLQuote{Content: []HTMLNode{HTMLPlain("quoted")}},
 			expected: "<blockquote>quoted</blockquote>",
 		},
 		{
 			name:     "with bold",
-			input:    HTMLQuote{HTMLPlain("hello "), HTMLBold("bold")},
-			expected: "<blockquote>hello <b>bold</b></blockquote>",
+			input:    HTMLQuote{Content: []HTMLNode{HTMLPlain("hello "), HTMLBold{Text: "bold"}}},
+			expected: "<blockquote>hello <strong>bold</strong></blockquote>",
 		},
 		{
 			name:     "empty",
@@ -342,7 +392,7 @@ func TestHTMLQuoteRender(t *testing.T) {
 }
 
 func TestHTMLBreakRender(t *testing.T) {
-	got := HTMLBreak(true).HTMLRender()
+	got := HTMLBreak{}.HTMLRender()
 	expected := "<br>"
 	if got != expected {
 		t.Errorf("HTMLBreak.HTMLRender() = %q, expected %q", got, expected)
@@ -357,18 +407,18 @@ func TestHTMLOrderedListRender(t *testing.T) {
 	}{
 		{
 			name: "single item",
-			input: HTMLOrderedList{
-				HTMLOrderedItem{HTMLPlain("first")},
-			},
+			input: HTMLOrderedList{Items: []HTMLOrderedItem{
+				HTMLOrderedItem{Content: []HTMLNode{HTMLPlain("first")}},
+			}},
 			expected: "<ol><li>first</li></ol>",
 		},
 		{
 			name: "multiple items",
-			input: HTMLOrderedList{
-				HTMLOrderedItem{HTMLPlain("first")},
-				HTMLOrderedItem{HTMLPlain("second")},
-				HTMLOrderedItem{HTMLPlain("third")},
-			},
+			input: HTMLOrderedList{Items: []HTMLOrderedItem{
+				HTMLOrderedItem{Content: []HTMLNode{HTMLPlain("first")}},
+				HTMLOrderedItem{Content: []HTMLNode{HTMLPlain("second")}},
+				HTMLOrderedItem{Content: []HTMLNode{HTMLPlain("third")}},
+			}},
 			expected: "<ol><li>first</li><li>second</li><li>third</li></ol>",
 		},
 		{
@@ -378,10 +428,10 @@ func TestHTMLOrderedListRender(t *testing.T) {
 		},
 		{
 			name: "item with bold",
-			input: HTMLOrderedList{
-				HTMLOrderedItem{HTMLBold("bold"), HTMLPlain(" item")},
-			},
-			expected: "<ol><li><b>bold</b> item</li></ol>",
+			input: HTMLOrderedList{Items: []HTMLOrderedItem{
+				HTMLOrderedItem{Content: []HTMLNode{HTMLBold{Text: "bold"}, HTMLPlain(" item")}},
+			}},
+			expected: "<ol><li><strong>bold</strong> item</li></ol>",
 		},
 	}
 	for _, tt := range tests {
@@ -402,17 +452,17 @@ func TestHTMLUnorderedListRender(t *testing.T) {
 	}{
 		{
 			name: "single item",
-			input: HTMLUnorderedList{
-				HTMLUnorderedItem{HTMLPlain("first")},
-			},
+			input: HTMLUnorderedList{Items: []HTMLUnorderedItem{
+				HTMLUnorderedItem{Content: []HTMLNode{HTMLPlain("first")}},
+			}},
 			expected: "<ul><li>first</li></ul>",
 		},
 		{
 			name: "multiple items",
-			input: HTMLUnorderedList{
-				HTMLUnorderedItem{HTMLPlain("first")},
-				HTMLUnorderedItem{HTMLPlain("second")},
-			},
+			input: HTMLUnorderedList{Items: []HTMLUnorderedItem{
+				HTMLUnorderedItem{Content: []HTMLNode{HTMLPlain("first")}},
+				HTMLUnorderedItem{Content: []HTMLNode{HTMLPlain("second")}},
+			}},
 			expected: "<ul><li>first</li><li>second</li></ul>",
 		},
 		{
@@ -422,10 +472,10 @@ func TestHTMLUnorderedListRender(t *testing.T) {
 		},
 		{
 			name: "item with italic",
-			input: HTMLUnorderedList{
-				HTMLUnorderedItem{HTMLItalic("italic"), HTMLPlain(" item")},
-			},
-			expected: "<ul><li><i>italic</i> item</li></ul>",
+			input: HTMLUnorderedList{Items: []HTMLUnorderedItem{
+				HTMLUnorderedItem{Content: []HTMLNode{HTMLItalic{Text: "italic"}, HTMLPlain(" item")}},
+			}},
+			expected: "<ul><li><em>italic</em> item</li></ul>",
 		},
 	}
 	for _, tt := range tests {
@@ -438,6 +488,201 @@ func TestHTMLUnorderedListRender(t *testing.T) {
 	}
 }
 
+func TestHTMLDefinitionListRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLDefinitionList
+		expected string
+	}{
+		{
+			name: "single entry",
+			input: HTMLDefinitionList{Entries: []HTMLDefinitionEntry{
+				{
+					Terms:        []HTMLDefinitionTerm{{Content: []HTMLNode{HTMLPlain("term")}}},
+					Descriptions: []HTMLDefinitionDescription{{Content: []HTMLNode{HTMLPlain("definition")}}},
+				},
+			}},
+			expected: "<dl><dt>term</dt><dd>definition</dd></dl>",
+		},
+		{
+			name: "multiple terms and definitions",
+			input: HTMLDefinitionList{Entries: []HTMLDefinitionEntry{
+				{
+					Terms:        []HTMLDefinitionTerm{{Content: []HTMLNode{HTMLPlain("a")}}, {Content: []HTMLNode{HTMLPlain("b")}}},
+					Descriptions: []HTMLDefinitionDescription{{Content: []HTMLNode{HTMLPlain("c")}}, {Content: []HTMLNode{HTMLBold{Text: "d"}}}},
+				},
+			}},
+			expected: "<dl><dt>a</dt><dt>b</dt><dd>c</dd><dd><strong>d</strong></dd></dl>",
+		},
+		{
+			name:     "empty list",
+			input:    HTMLDefinitionList{},
+			expected: "<dl></dl>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("HTMLDefinitionList.HTMLRender() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTMLTableRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLTable
+		expected string
+	}{
+		{
+			name:     "empty table",
+			input:    HTMLTable{},
+			expected: "<table><thead><tr></tr></thead><tbody></tbody></table>",
+		},
+		{
+			name: "header and rows",
+			input: HTMLTable{
+				Header: HTMLTableHeader{{HTMLPlain("A")}, {HTMLItalic{Text: "B"}}},
+				Rows:   []HTMLTableRow{{{HTMLPlain("1")}, {HTMLPlain("2")}}},
+			},
+			expected: "<table><thead><tr><th>A</th><th><em>B</em></th></tr></thead><tbody><tr><td>1</td><td>2</td></tr></tbody></table>",
+		},
+		{
+			name: "with attributes",
+			input: HTMLTable{
+				Header:     HTMLTableHeader{{HTMLPlain("A")}},
+				Attributes: Attributes{"id": "tbl:a"},
+			},
+			expected: "<table id='tbl:a'><thead><tr><th>A</th></tr></thead><tbody></tbody></table>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("HTMLTable.HTMLRender() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTMLAdmonitionRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLAdmonition
+		expected string
+	}{
+		{
+			name:     "default title",
+			input:    HTMLAdmonition{Type: "warning", Content: []HTMLNode{HTMLParagraph{Content: []HTMLNode{HTMLPlain("text")}}}},
+			expected: "<div class='admonition warning'><p class='admonition-title'>Warning</p><p>text</p></div>",
+		},
+		{
+			name:     "custom title",
+			input:    HTMLAdmonition{Type: "tip", Title: []HTMLNode{HTMLBold{Text: "Pro tip"}}, Content: []HTMLNode{HTMLParagraph{Content: []HTMLNode{HTMLPlain("text")}}}},
+			expected: "<div class='admonition tip'><p class='admonition-title'><strong>Pro tip</strong></p><p>text</p></div>",
+		},
+		{
+			name:     "empty",
+			input:    HTMLAdmonition{Type: "note"},
+			expected: "<div class='admonition note'><p class='admonition-title'>Note</p></div>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("HTMLAdmonition.HTMLRender() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTMLMathRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLNode
+		expected string
+	}{
+		{name: "inline", input: HTMLInlineMath{Text: "x^2"}, expected: "<span class='math inline'>\\(x^2\\)</span>"},
+		{name: "inline escaped", input: HTMLInlineMath{Text: "a<b & c"}, expected: "<span class='math inline'>\\(a&lt;b &amp; c\\)</span>"},
+		{name: "display", input: HTMLDisplayMath{Text: "\\frac{1}{2}"}, expected: "<div class='math display'>\\[\\frac{1}{2}\\]</div>"},
+		{name: "display escaped", input: HTMLDisplayMath{Text: "x > y"}, expected: "<div class='math display'>\\[x &gt; y\\]</div>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("%T.HTMLRender() = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTMLAttributesRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    HTMLNode
+		expected string
+	}{
+		{name: "inline", input: HTMLBold{Text: "bold", Attributes: Attributes{"class": "x"}}, expected: "<strong class='x'>bold</strong>"},
+		{name: "block", input: HTMLQuote{Content: []HTMLNode{HTMLPlain("q")}, Attributes: Attributes{"id": "q"}}, expected: "<blockquote id='q'>q</blockquote>"},
+		{name: "break", input: HTMLBreak{Attributes: Attributes{"class": "x"}}, expected: "<br class='x'>"},
+		{
+			name:     "list items",
+			input:    HTMLUnorderedList{Items: []HTMLUnorderedItem{{Content: []HTMLNode{HTMLPlain("a")}, Attributes: Attributes{"class": "done"}}}, Attributes: Attributes{"id": "l"}},
+			expected: "<ul id='l'><li class='done'>a</li></ul>",
+		},
+		{name: "built-in classes first", input: HTMLInlineMath{Text: "x", Attributes: Attributes{"class": "big"}}, expected: "<span class='math inline big'>\\(x\\)</span>"},
+		{name: "admonition", input: HTMLAdmonition{Type: "note", Attributes: Attributes{"id": "n"}}, expected: "<div id='n' class='admonition note'><p class='admonition-title'>Note</p></div>"},
+		{name: "new wiki page", input: HTMLWikiLink{Content: []HTMLNode{HTMLPlain("p")}, Link: "p", Attributes: Attributes{"class": "wiki"}}, expected: "<a href='p' class='new-page wiki'>p</a>"},
+		{name: "escaped link", input: HTMLHyperlink{Content: []HTMLNode{HTMLPlain("q")}, Link: "/?a=1&b='2'"}, expected: "<a href='/?a=1&amp;b=&#39;2&#39;'>q</a>"},
+		{name: "link keeps its href", input: HTMLHyperlink{Link: "a.com", Attributes: Attributes{"href": "b.com"}}, expected: "<a href='a.com'></a>"},
+		{name: "emoji character", input: HTMLEmoji{Name: "tada", Character: "🎉", Attributes: Attributes{"title": "tada"}}, expected: "<span title='tada'>🎉</span>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("%T.HTMLRender() = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTMLClassHelpers(t *testing.T) {
+	attributes := Attributes{"class": "a"}
+	node := HTMLNode(HTMLParagraph{Content: []HTMLNode{HTMLPlain("p")}, Attributes: attributes})
+	node = AddClass(node, "b")
+	node = RemoveClass(node, "a")
+	node = SetAttribute(node, "data-x", "1")
+	expected := "<p class='b' data-x='1'>p</p>"
+	if got := node.HTMLRender(); got != expected {
+		t.Errorf("decorated HTMLParagraph.HTMLRender() = %q, expected %q", got, expected)
+	}
+	if diff := cmp.Diff(attributes, Attributes{"class": "a"}); diff != "" {
+		t.Errorf("class helpers modified the original attributes\n  Diff:     %s", diff)
+	}
+	item := AddClass(HTMLOrderedItem{Content: []HTMLNode{HTMLPlain("i")}}, "done")
+	if got, expected := item.HTMLRender(), "<li class='done'>i</li>"; got != expected {
+		t.Errorf("decorated HTMLOrderedItem.HTMLRender() = %q, expected %q", got, expected)
+	}
+	if got := AddClass(HTMLPlain("text"), "b"); got != HTMLPlain("text") {
+		t.Errorf("AddClass(HTMLPlain) = %#v, expected it unchanged", got)
+	}
+}
+
+func TestToHTMLCopiesAttributes(t *testing.T) {
+	paragraph := Paragraph{Content: []Node{Plain("p")}, Attributes: Attributes{"class": "a"}}
+	html := paragraph.ToHTML().(HTMLParagraph)
+	html.Attributes.AddClass("added")
+	if diff := cmp.Diff(paragraph.Attributes, Attributes{"class": "a"}); diff != "" {
+		t.Errorf("changing the HTML attributes changed those of the document\n  Diff:     %s", diff)
+	}
+}
+
 func TestHtmlRender(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -456,13 +701,13 @@ func TestHtmlRender(t *testing.T) {
 		},
 		{
 			name:     "mixed nodes",
-			input:    []HTMLNode{HTMLPlain("hello "), HTMLBold("world")},
-			expected: "hello <b>world</b>",
+			input:    []HTMLNode{HTMLPlain("hello "), HTMLBold{Text: "world"}},
+			expected: "hello <strong>world</strong>",
 		},
 		{
 			name:     "multiple inline types",
-			input:    []HTMLNode{HTMLItalic("a"), HTMLPlain(" "), HTMLCrossed("b"), HTMLPlain(" "), HTMLInlineCode("c")},
-			expected: "<i>a</i> <strike>b</strike> <code>c</code>",
+			input:    []HTMLNode{HTMLItalic{Text: "a"}, HTMLPlain(" "), HTMLCrossed{Text: "b"}, HTMLPlain(" "), HTMLInlineCode{Text: "c"}},
+			expected: "<em>a</em> <del>b</del> <code>c</code>",
 		},
 	}
 	for _, tt := range tests {
@@ -474,3 +719,87 @@ func TestHtmlRender(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "paragraph", input: "hello **world** & [link](https://example.com)"},
+		{name: "lists", input: "1. one\n2. two\n\n- a\n- b"},
+		{name: "nested", input: ":::note Title\n> quoted `code`\n:::"},
+		{name: "figure", input: "![alt](image.png \"Title\")"},
+		{name: "table", input: "| a | b |\n| - | - |\n| 1 | 2 |"},
+		{name: "large", input: strings.Repeat("# Header\n\nsome *text* here\n\n", 2000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := MarkdownToHTML(tt.input).HTMLRender()
+			builder := new(strings.Builder)
+			if err := MarkdownToWriter(builder, tt.input); err != nil {
+				t.Fatalf("MarkdownToWriter() error = %v", err)
+			}
+			got := builder.String()
+			if got != expected {
+				t.Errorf("got:\n%v\nexpected:\n%v\nDiff:\n%v", got, expected, cmp.Diff(got, expected))
+			}
+		})
+	}
+}
+
+// foreignNode is an HTML node from outside the package
+type foreignNode string
+
+func (f foreignNode) HTMLRender() string {
+	return "<x-foreign>" + string(f) + "</x-foreign>"
+}
+
+func TestWriteHTMLForeignNode(t *testing.T) {
+	node := HTMLDiv{Content: []HTMLNode{HTMLPlain("a"), foreignNode("b")}}
+	builder := new(strings.Builder)
+	if err := WriteHTML(builder, node); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	got := builder.String()
+	expected := "<div>a<x-foreign>b</x-foreign></div>"
+	if got != expected {
+		t.Errorf("got:\n%v\nexpected:\n%v\nDiff:\n%v", got, expected, cmp.Diff(got, expected))
+	}
+}
+
+// failingWriter accepts limit bytes, then fails
+type failingWriter struct {
+	limit int
+}
+
+var errWrite = errors.New("write failed")
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n := f.limit
+		f.limit = 0
+		return n, errWrite
+	}
+	f.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteHTMLError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		limit int
+	}{
+		{name: "small document", input: "hello", limit: 0},
+		{name: "large document", input: strings.Repeat("some *text* here\n\n", 2000), limit: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MarkdownToWriter(&failingWriter{limit: tt.limit}, tt.input)
+			if !errors.Is(err, errWrite) {
+				t.Errorf("got: %v, expected: %v", err, errWrite)
+			}
+		})
+	}
+}
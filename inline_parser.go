@@ -3,18 +3,24 @@ package markdownrenderer
 import (
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 const (
-	BOLDDELIMITER1      = "**"
-	BOLDDELIMITER2      = "__"
-	ITALICDELIMITER1    = "*"
-	ITALICDELIMITER2    = "_"
-	UNDERLINEDELIMITER  = "-"
-	INLINECODEDELIMITER = "`"
-	CROSSEDDELIMITER    = "~"
-	IMAGEREGEX          = "!\\[(.*?)\\]\\((.*?)\\)"
-	LINKREGEX           = "\\[(.*?)\\]\\((.*?)\\)"
+	BOLDDELIMITER1       = "**"
+	BOLDDELIMITER2       = "__"
+	ITALICDELIMITER1     = "*"
+	ITALICDELIMITER2     = "_"
+	UNDERLINEDELIMITER   = ",,"
+	INLINECODEDELIMITER  = "`"
+	CROSSEDDELIMITER     = "~~"
+	HIGHLIGHTDELIMITER   = "=="
+	SUPERSCRIPTDELIMITER = "^"
+	SUBSCRIPTDELIMITER   = "~"
+	INSERTDELIMITER      = "++"
+	IMAGEREGEX           = "!\\[(.*?)\\]\\((.*?)\\)(" + ATTRIBUTESREGEX + ")?"
+	LINKREGEX            = "\\[(.*?)\\]\\((.*?)\\)(" + ATTRIBUTESREGEX + ")?"
+	WIKILINKREGEX        = "\\[\\[([^\\]|]+?)(?:\\|([^\\]]*?))?\\]\\]"
 )
 
 const (
@@ -24,29 +30,72 @@ const (
 	UNDERLINE
 	INLINECODE
 	CROSSED
+	HIGHLIGHT
+	SUPERSCRIPT
+	SUBSCRIPT
+	INSERTED
 )
 
+type MathDelimiter struct {
+	Open  string
+	Close string
+}
+
+// InlineMathDelimiters and DisplayMathDelimiters configure the delimiters
+// recognised around TeX formulas, in order of precedence
+var InlineMathDelimiters = []MathDelimiter{{"$", "$"}, {"\\(", "\\)"}}
+var DisplayMathDelimiters = []MathDelimiter{{"$$", "$$"}, {"\\[", "\\]"}}
+
+// Strikethrough parses text between double tildes as crossed out
+var Strikethrough = true
+
 var imagePattern = regexp.MustCompile(IMAGEREGEX)
 var hyperlinkPattern = regexp.MustCompile(LINKREGEX)
+var wikiLinkPattern = regexp.MustCompile(WIKILINKREGEX)
 
 // TODO: handle escapes and nested formatting; possibly recursive implementation
 func SimpleParser(line string) []Node {
-	if len(line) < 2 {
-		return []Node{Plain(line)}
+	return nodesOf(currentParser().parseSimple(NewSource(line)))
+}
+
+func (p *Parser) parseSimple(line Source) []*Element {
+	text := line.Text
+	if len(text) < 2 {
+		return []*Element{line.Element(Plain(text))}
 	}
 
-	window := line[:1]
+	window := text[:1]
 	inside := false
 	delimiter := ""
 	inlineType := PLAIN
-	text := window
-	nodes := []Node{}
+	content := window
+	// where the node being read starts, delimiter included
+	start := 0
+	elements := []*Element{}
 
-	for i := 1; i < len(line); i++ {
-		current := string(line[i])
+	for i := 1; i < len(text); i++ {
+		current := string(text[i])
 		window = window[len(window)-1:] + current
+		// known shortcodes, which may hold underscores, are never emphasis
+		if !inside {
+			if n := shortcodeAt(text, i-1); n > 0 {
+				content += text[i : i-1+n]
+				i += n - 2
+				window = text[i : i+1]
+				continue
+			}
+		} else if n := shortcodeAt(text, i); n > 0 && inlineType != INLINECODE {
+			content += text[i : i+n]
+			i += n - 1
+			window = text[i : i+1]
+			continue
+		}
 		if !inside {
 			switch {
+			case p.enabled(EXTUNDERLINE) && strings.HasPrefix(window, UNDERLINEDELIMITER):
+				delimiter = UNDERLINEDELIMITER
+				inlineType = UNDERLINE
+				inside = true
 			case strings.HasPrefix(window, BOLDDELIMITER1):
 				delimiter = BOLDDELIMITER1
 				inlineType = BOLD
@@ -55,6 +104,26 @@ func SimpleParser(line string) []Node {
 				delimiter = BOLDDELIMITER2
 				inlineType = BOLD
 				inside = true
+			case p.enabled(EXTHIGHLIGHT) && strings.HasPrefix(window, HIGHLIGHTDELIMITER):
+				delimiter = HIGHLIGHTDELIMITER
+				inlineType = HIGHLIGHT
+				inside = true
+			case p.enabled(EXTINSERT) && strings.HasPrefix(window, INSERTDELIMITER):
+				delimiter = INSERTDELIMITER
+				inlineType = INSERTED
+				inside = true
+			case p.options.Strikethrough && strings.HasPrefix(window, CROSSEDDELIMITER):
+				delimiter = CROSSEDDELIMITER
+				inlineType = CROSSED
+				inside = true
+			case p.enabled(EXTSUBSCRIPT) && strings.HasPrefix(window, SUBSCRIPTDELIMITER):
+				delimiter = SUBSCRIPTDELIMITER
+				inlineType = SUBSCRIPT
+				inside = true
+			case p.enabled(EXTSUPERSCRIPT) && strings.HasPrefix(window, SUPERSCRIPTDELIMITER):
+				delimiter = SUPERSCRIPTDELIMITER
+				inlineType = SUPERSCRIPT
+				inside = true
 			case strings.HasPrefix(window, ITALICDELIMITER1):
 				delimiter = ITALICDELIMITER1
 				inlineType = ITALIC
@@ -63,145 +132,309 @@ func SimpleParser(line string) []Node {
 				delimiter = ITALICDELIMITER2
 				inlineType = ITALIC
 				inside = true
-			case strings.HasPrefix(window, UNDERLINEDELIMITER):
-				delimiter = UNDERLINEDELIMITER
-				inlineType = UNDERLINE
-				inside = true
 			case strings.HasPrefix(window, INLINECODEDELIMITER):
 				delimiter = INLINECODEDELIMITER
 				inlineType = INLINECODE
 				inside = true
-			case strings.HasPrefix(window, CROSSEDDELIMITER):
-				delimiter = CROSSEDDELIMITER
-				inlineType = CROSSED
-				inside = true
 			default:
 				delimiter = ""
 				inside = false
 			}
 			if inside {
-				text = strings.TrimSuffix(text+current, window)
-				if len(text) > 0 {
-					nodes = append(nodes, Plain(text))
+				content = strings.TrimSuffix(content+current, window)
+				if len(content) > 0 {
+					elements = append(elements, line.Slice(start, i-1).Element(Plain(content)))
 				}
-				text = strings.TrimPrefix(window, delimiter)
-				if i < len(line) {
-					window = string(line[i])
+				// the window starts with the delimiter
+				start = i - 1
+				content = strings.TrimPrefix(window, delimiter)
+				if i < len(text) {
+					window = string(text[i])
 				}
 				continue
 			}
 		} else {
 			if strings.HasSuffix(window, delimiter) {
-				text = strings.TrimSuffix(text+current, delimiter)
-				nodes = append(nodes, SetType(text, inlineType))
-				text = ""
+				content = strings.TrimSuffix(content+current, delimiter)
+				elements = append(elements, line.Slice(start, i+1).Element(SetType(content, inlineType)))
+				content = ""
 				inlineType = PLAIN
 				delimiter = ""
 				inside = false
 				i++
-				if i < len(line) {
-					window = string(line[i])
-					text = window
+				start = i
+				if i < len(text) {
+					window = string(text[i])
+					content = window
 				}
 				continue
 			}
 		}
-		text += current
+		content += current
 	}
-	if len(text) != 0 {
-		nodes = append(nodes, SetType(text, inlineType))
+	if len(content) != 0 {
+		elements = append(elements, line.Slice(start, len(text)).Element(SetType(content, inlineType)))
 	}
-	return nodes
+	return elements
 }
 
 func ImageParser(line string) []Node {
-	found := imagePattern.FindAllStringSubmatch(line, -1)
-	textNodes := imagePattern.Split(line, -1)
-	nodes := []Node{}
+	return nodesOf(currentParser().parseImages(NewSource(line)))
+}
+
+func (p *Parser) parseImages(line Source) []*Element {
+	return parseMatches(line, imagePattern, func(match Source, groups []int) *Element {
+		destination := match.Slice(groups[4], groups[5])
+		path, title := splitTitle(destination.Text)
+		attributes, _ := ParseAttributes(groupText(match.Text, groups, 3))
+		image := Image{Path: path, Title: title, Attributes: attributes}
+		return match.Element(image, p.parseSimple(match.Slice(groups[2], groups[3]))...)
+	})
+}
 
-	if len(textNodes) == 0 {
-		return nodes
+// splitTitle splits an optional quoted title off an image destination, as in
+// (image.png "A title"). Double quotes within double-quoted titles may be
+// escaped with a backslash.
+func splitTitle(destination string) (string, string) {
+	start, end, isT := titleBounds(destination)
+	if !isT {
+		return strings.TrimSpace(destination), ""
 	}
-	firstNode := textNodes[0]
-	if len(firstNode) > 0 {
-		nodes = append(nodes, Plain(firstNode))
+	title := destination[start:end]
+	if destination[end] == '"' {
+		title = strings.ReplaceAll(title, "\\\"", "\"")
 	}
+	return strings.TrimSpace(destination[:start-2]), title
+}
 
-	for i := 0; i < len(found); i++ {
-		im := found[i]
-		content := im[1]
-		path := im[2]
-		imageNode := Image{
-			Content: SimpleParser(content),
-			Path:    path,
+// titleBounds returns where the quoted title of an image destination starts
+// and ends, quotes excluded
+func titleBounds(destination string) (int, int, bool) {
+	trimmed := strings.TrimSpace(destination)
+	offset := len(destination) - len(strings.TrimLeftFunc(destination, unicode.IsSpace))
+	for _, quote := range []string{"\"", "'"} {
+		if !strings.HasSuffix(trimmed, quote) {
+			continue
 		}
-		textNode := textNodes[i+1]
-		nodes = append(nodes, imageNode)
-		if len(textNode) > 0 {
-			nodes = append(nodes, Plain(textNode))
+		start := strings.Index(trimmed, " "+quote)
+		if start < 0 || start+2 > len(trimmed)-1 {
+			continue
 		}
+		return offset + start + 2, offset + len(trimmed) - 1, true
 	}
+	return 0, 0, false
+}
 
-	return nodes
+func WikiLinkParser(line string) []Node {
+	return nodesOf(currentParser().parseWikiLinks(NewSource(line)))
+}
+
+func (p *Parser) parseWikiLinks(line Source) []*Element {
+	return parseMatches(line, wikiLinkPattern, func(match Source, groups []int) *Element {
+		page := strings.TrimSpace(groupText(match.Text, groups, 1))
+		label := match.Slice(groups[2], groups[3]).TrimSpace()
+		if groups[4] >= 0 && len(strings.TrimSpace(groupText(match.Text, groups, 2))) > 0 {
+			label = match.Slice(groups[4], groups[5])
+		}
+		return match.Element(WikiLink{Page: page}, p.parseSimple(label)...)
+	})
 }
 
 func HyperlinkParser(line string) []Node {
-	found := hyperlinkPattern.FindAllStringSubmatch(line, -1)
-	textNodes := hyperlinkPattern.Split(line, -1)
-	nodes := []Node{}
+	return nodesOf(currentParser().parseHyperlinks(NewSource(line)))
+}
+
+func (p *Parser) parseHyperlinks(line Source) []*Element {
+	return parseMatches(line, hyperlinkPattern, func(match Source, groups []int) *Element {
+		attributes, _ := ParseAttributes(groupText(match.Text, groups, 3))
+		link := Hyperlink{Link: groupText(match.Text, groups, 2), Attributes: attributes}
+		return match.Element(link, p.parseSimple(match.Slice(groups[2], groups[3]))...)
+	})
+}
+
+// parseMatches splits line into the elements parse returns for every match
+// of pattern, given the source of the match and its submatch indices within
+// it, and the text around them
+func parseMatches(line Source, pattern *regexp.Regexp, parse func(match Source, groups []int) *Element) []*Element {
+	elements := []*Element{}
+	last := 0
+	for _, groups := range pattern.FindAllStringSubmatchIndex(line.Text, -1) {
+		start, end := groups[0], groups[1]
+		if start > last {
+			elements = append(elements, plainElement(line.Slice(last, start)))
+		}
+		for i := range groups {
+			if groups[i] >= 0 {
+				groups[i] -= start
+			}
+		}
+		elements = append(elements, parse(line.Slice(start, end), groups))
+		last = end
+	}
+	if last < len(line.Text) {
+		elements = append(elements, plainElement(line.Slice(last, len(line.Text))))
+	}
+
+	return elements
+}
 
-	if len(textNodes) == 0 {
-		return nodes
+func groupText(text string, groups []int, i int) string {
+	if groups[2*i] < 0 {
+		return ""
 	}
-	firstNode := textNodes[0]
-	if len(firstNode) > 0 {
-		nodes = append(nodes, Plain(firstNode))
+	return text[groups[2*i]:groups[2*i+1]]
+}
+
+func plainElement(text Source) *Element {
+	return text.Element(Plain(text.Text))
+}
+
+// MathParser extracts inline formulas so that later passes leave their TeX
+// source untouched. Code spans are skipped, and a "$" formula must not start
+// or end with a space nor be followed by a digit, so prices stay plain text.
+func MathParser(line string) []Node {
+	return nodesOf(currentParser().parseMath(NewSource(line)))
+}
+
+func (p *Parser) parseMath(line Source) []*Element {
+	text := line.Text
+	elements := []*Element{}
+	last := 0
+
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], INLINECODEDELIMITER) {
+			end := strings.Index(text[i+1:], INLINECODEDELIMITER)
+			if end >= 0 {
+				i += end + 2
+				continue
+			}
+		}
+		// escaped dollars and display delimiters are never inline math
+		if skip := p.mathSkip(text[i:]); skip > 0 {
+			i += skip
+			continue
+		}
+		formula, length, isMath := p.mathAt(text[i:])
+		if !isMath {
+			i++
+			continue
+		}
+		if i > last {
+			elements = append(elements, plainElement(line.Slice(last, i)))
+		}
+		elements = append(elements, line.Slice(i, i+length).Element(InlineMath(formula)))
+		i += length
+		last = i
+	}
+	if last < len(text) {
+		elements = append(elements, plainElement(line.Slice(last, len(text))))
+	}
+
+	return elements
+}
+
+func (p *Parser) mathSkip(line string) int {
+	if strings.HasPrefix(line, "\\$") {
+		return 2
 	}
+	for _, d := range p.options.DisplayMathDelimiters {
+		if strings.HasPrefix(line, d.Open) {
+			return len(d.Open)
+		}
+	}
+	return 0
+}
 
-	for i := 0; i < len(found); i++ {
-		link := found[i]
-		content := link[1]
-		path := link[2]
-		linkedNode := Hyperlink{
-			Content: SimpleParser(content),
-			Link:    path,
+func (p *Parser) mathAt(line string) (string, int, bool) {
+	for _, d := range p.options.InlineMathDelimiters {
+		if !strings.HasPrefix(line, d.Open) {
+			continue
 		}
-		textNode := textNodes[i+1]
-		nodes = append(nodes, linkedNode)
-		if len(textNode) > 0 {
-			nodes = append(nodes, Plain(textNode))
+		rest := line[len(d.Open):]
+		end := strings.Index(rest, d.Close)
+		if end <= 0 {
+			continue
 		}
+		formula := rest[:end]
+		after := rest[end+len(d.Close):]
+		if d.Open == "$" {
+			if strings.HasPrefix(formula, " ") || strings.HasSuffix(formula, " ") {
+				continue
+			}
+			if len(after) > 0 && after[0] >= '0' && after[0] <= '9' {
+				continue
+			}
+		}
+		return formula, len(d.Open) + end + len(d.Close), true
 	}
 
-	return nodes
+	return "", 0, false
 }
 
-func nodePushFunc(nodes []Node, parseFunc func(string) []Node) []Node {
-	newNodes := []Node{}
-	for _, n := range nodes {
-		switch v := n.(type) {
-		case Plain:
-			stringNode := string(v)
-			newNodes = append(newNodes, parseFunc(stringNode)...)
-		default:
-			newNodes = append(newNodes, v)
+// parsePlain replaces the plain elements parsed from line with those
+// parse returns for their source, leaving the others untouched
+func parsePlain(line Source, elements []*Element, parse func(text Source) []*Element) []*Element {
+	newElements := []*Element{}
+	for _, e := range elements {
+		if _, isP := e.Node.(Plain); !isP {
+			newElements = append(newElements, e)
+			continue
 		}
+		newElements = append(newElements, parse(line.within(e.Range))...)
 	}
-	return newNodes
+	return newElements
 }
 
 func NodeParser(nodes []Node) []Node {
-	nodes = nodePushFunc(nodes, ImageParser)
-	nodes = nodePushFunc(nodes, HyperlinkParser)
-	nodes = nodePushFunc(nodes, SimpleParser)
+	return currentParser().ParseInlines(nodes)
+}
 
-	return nodes
+// ParseInlines runs the inline rules, in order, on the plain text of nodes
+func (p *Parser) ParseInlines(nodes []Node) []Node {
+	newNodes := []Node{}
+	for _, n := range nodes {
+		if text, isP := n.(Plain); isP {
+			newNodes = append(newNodes, nodesOf(p.ParseLine(NewSource(string(text))))...)
+		} else {
+			newNodes = append(newNodes, n)
+		}
+	}
+	return newNodes
 }
 
 func LineParser(line string) []Node {
-	nodes := []Node{Plain(line)}
+	return nodesOf(currentParser().ParseLine(NewSource(line)))
+}
 
-	return NodeParser(nodes)
+// ParseLine parses the inline elements of a line of text: the inline rules
+// run in order on the plain text left, then abbreviations are marked and,
+// with EXTTYPOGRAPHER, punctuation is smartened
+func (p *Parser) ParseLine(line Source) []*Element {
+	elements := []*Element{plainElement(line)}
+	for _, rule := range p.inlineRules {
+		elements = parsePlain(line, elements, func(text Source) []*Element {
+			return rule.Parse(p, text)
+		})
+	}
+	if len(p.abbreviations) > 0 {
+		elements = parsePlain(line, elements, func(text Source) []*Element {
+			return abbreviateText(text, p.abbreviationWords, p.abbreviations)
+		})
+	}
+	if p.enabled(EXTTYPOGRAPHER) {
+		p.typographer(elements)
+	}
+
+	return elements
+}
+
+// nodesOf builds the nodes of elements
+func nodesOf(elements []*Element) []Node {
+	nodes := make([]Node, 0, len(elements))
+	for _, e := range elements {
+		nodes = append(nodes, e.Build())
+	}
+	return nodes
 }
 
 func SetType(text string, TYPE int) Node {
@@ -217,6 +450,14 @@ func SetType(text string, TYPE int) Node {
 		typedText = InlineCode(text)
 	case CROSSED:
 		typedText = Crossed(text)
+	case HIGHLIGHT:
+		typedText = Highlight(text)
+	case SUPERSCRIPT:
+		typedText = Superscript(text)
+	case SUBSCRIPT:
+		typedText = Subscript(text)
+	case INSERTED:
+		typedText = Inserted(text)
 	default:
 		typedText = Plain(text)
 	}
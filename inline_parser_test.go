@@ -27,6 +27,11 @@ func TestImageParser(t *testing.T) {
 			input:    "![alt text](image.png)",
 			expected: []Node{Image{Content: []Node{Plain("alt text")}, Path: "image.png"}},
 		},
+		{
+			name:     "image with title",
+			input:    `![alt](img.png "A title") ![alt](b.png 'B')`,
+			expected: []Node{Image{Content: []Node{Plain("alt")}, Path: "img.png", Title: "A title"}, Plain(" "), Image{Content: []Node{Plain("alt")}, Path: "b.png", Title: "B"}},
+		},
 		{
 			name:     "image with plain text before",
 			input:    "hello ![alt](img.png)",
@@ -47,6 +52,11 @@ func TestImageParser(t *testing.T) {
 			input:    "![a](1.png)![b](2.png)",
 			expected: []Node{Image{Content: []Node{Plain("a")}, Path: "1.png"}, Image{Content: []Node{Plain("b")}, Path: "2.png"}},
 		},
+		{
+			name:     "image with attributes",
+			input:    "![alt](img.png){width=300 .wide} text",
+			expected: []Node{Image{Content: []Node{Plain("alt")}, Path: "img.png", Attributes: Attributes{"width": "300", "class": "wide"}}, Plain(" text")},
+		},
 		{
 			name:     "image with bold in alt text",
 			input:    "![**bold**](img.png)",
@@ -137,6 +147,63 @@ func TestHyperlinkParser(t *testing.T) {
 	}
 }
 
+func TestWikiLinkParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Node
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: []Node{},
+		},
+		{
+			name:     "plain text only",
+			input:    "hello world",
+			expected: []Node{Plain("hello world")},
+		},
+		{
+			name:     "page name",
+			input:    "see [[Page Name]]",
+			expected: []Node{Plain("see "), WikiLink{Content: []Node{Plain("Page Name")}, Page: "Page Name"}},
+		},
+		{
+			name:     "page with label",
+			input:    "[[Page Name|the **page**]] here",
+			expected: []Node{WikiLink{Content: []Node{Plain("the "), Bold("page")}, Page: "Page Name"}, Plain(" here")},
+		},
+		{
+			name:     "empty label uses page",
+			input:    "[[Page|]]",
+			expected: []Node{WikiLink{Content: []Node{Plain("Page")}, Page: "Page"}},
+		},
+		{
+			name:  "multiple links",
+			input: "[[A]] and [[B|b]]",
+			expected: []Node{
+				WikiLink{Content: []Node{Plain("A")}, Page: "A"},
+				Plain(" and "),
+				WikiLink{Content: []Node{Plain("b")}, Page: "B"},
+			},
+		},
+		{
+			name:     "single brackets are not wiki links",
+			input:    "[not](a link)",
+			expected: []Node{Plain("[not](a link)")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WikiLinkParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("WikiLinkParser(%q)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
 func TestSimpleParser(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -179,9 +246,9 @@ func TestSimpleParser(t *testing.T) {
 			expected: []Node{Italic("italic")},
 		},
 		{
-			name:     "underline with dash",
-			input:    "-underline-",
-			expected: []Node{Underline("underline")},
+			name:     "hyphenated words are plain",
+			input:    "well-known-thing",
+			expected: []Node{Plain("well-known-thing")},
 		},
 		{
 			name:     "inline code with backtick",
@@ -190,9 +257,14 @@ func TestSimpleParser(t *testing.T) {
 		},
 		{
 			name:     "crossed with tilde",
-			input:    "~crossed~",
+			input:    "~~crossed~~",
 			expected: []Node{Crossed("crossed")},
 		},
+		{
+			name:     "single tilde is plain",
+			input:    "~approx~",
+			expected: []Node{Plain("~approx~")},
+		},
 		{
 			name:     "plain before bold",
 			input:    "hello **world**",
@@ -205,7 +277,7 @@ func TestSimpleParser(t *testing.T) {
 		},
 		{
 			name:     "original example with italic crossed and bold",
-			input:    "*Ci ao c om*~ok ~**e va**",
+			input:    "*Ci ao c om*~~ok ~~**e va**",
 			expected: []Node{Italic("Ci ao c om"), Crossed("ok "), Bold("e va")},
 		},
 		{
@@ -219,9 +291,9 @@ func TestSimpleParser(t *testing.T) {
 			expected: []Node{Bold("bold"), Italic("italic")},
 		},
 		{
-			name:     "crossed then underline",
-			input:    "~abc~ -def-",
-			expected: []Node{Crossed("abc"), Plain(" "), Underline("def")},
+			name:     "crossed then bold",
+			input:    "~~abc~~ __def__",
+			expected: []Node{Crossed("abc"), Plain(" "), Bold("def")},
 		},
 		{
 			name:     "inline code then bold",
@@ -260,6 +332,96 @@ func TestSimpleParser(t *testing.T) {
 	}
 }
 
+func TestSimpleParserExtensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions Extension
+		input      string
+		expected   []Node
+	}{
+		{
+			name:       "highlight",
+			extensions: EXTHIGHLIGHT,
+			input:      "a ==marked== word",
+			expected:   []Node{Plain("a "), Highlight("marked"), Plain(" word")},
+		},
+		{
+			name:       "highlight disabled",
+			extensions: 0,
+			input:      "a ==marked== word",
+			expected:   []Node{Plain("a ==marked== word")},
+		},
+		{
+			name:       "superscript",
+			extensions: EXTSUPERSCRIPT,
+			input:      "E=mc^2^",
+			expected:   []Node{Plain("E=mc"), Superscript("2")},
+		},
+		{
+			name:       "subscript",
+			extensions: EXTSUBSCRIPT,
+			input:      "H~2~O",
+			expected:   []Node{Plain("H"), Subscript("2"), Plain("O")},
+		},
+		{
+			name:       "strikethrough with subscript enabled",
+			extensions: EXTSUBSCRIPT,
+			input:      "~~gone~~ and x~i~",
+			expected:   []Node{Crossed("gone"), Plain(" and x"), Subscript("i")},
+		},
+		{
+			name:       "underline",
+			extensions: EXTUNDERLINE,
+			input:      ",,under,, **bold**",
+			expected:   []Node{Underline("under"), Plain(" "), Bold("bold")},
+		},
+		{
+			name:       "double underscore stays bold with underline enabled",
+			extensions: EXTUNDERLINE,
+			input:      "__bold__ ,,under,,",
+			expected:   []Node{Bold("bold"), Plain(" "), Underline("under")},
+		},
+		{
+			name:       "underline disabled",
+			extensions: 0,
+			input:      ",,under,, __bold__",
+			expected:   []Node{Plain(",,under,, "), Bold("bold")},
+		},
+		{
+			name:       "shortcodes are not emphasis",
+			extensions: 0,
+			input:      ":white_check_mark: _it_ :not_an_emoji:",
+			expected:   []Node{Plain(":white_check_mark: "), Italic("it"), Plain(" :not"), Italic("an"), Plain("emoji:")},
+		},
+		{
+			name:       "inserted",
+			extensions: EXTINSERT,
+			input:      "++new++ text",
+			expected:   []Node{Inserted("new"), Plain(" text")},
+		},
+		{
+			name:       "all extensions",
+			extensions: EXTHIGHLIGHT | EXTSUPERSCRIPT | EXTSUBSCRIPT | EXTINSERT,
+			input:      "==a== ^b^ ~c~ ++d++ **e**",
+			expected: []Node{
+				Highlight("a"), Plain(" "), Superscript("b"), Plain(" "), Subscript("c"),
+				Plain(" "), Inserted("d"), Plain(" "), Bold("e"),
+			},
+		},
+	}
+
+	defer func() { Extensions = 0 }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Extensions = tt.extensions
+			got := SimpleParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("SimpleParser(%q)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
 func TestNodeParser(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -326,6 +488,16 @@ func TestNodeParser(t *testing.T) {
 			input:    []Node{Plain("**a**"), Plain(" "), Plain("**b**")},
 			expected: []Node{Bold("a"), Plain(" "), Bold("b")},
 		},
+		{
+			name:     "wiki link before hyperlink",
+			input:    []Node{Plain("[[Home]] and [link](b.com)")},
+			expected: []Node{WikiLink{Content: []Node{Plain("Home")}, Page: "Home"}, Plain(" and "), Hyperlink{Content: []Node{Plain("link")}, Link: "b.com"}},
+		},
+		{
+			name:     "math is not formatted",
+			input:    []Node{Plain("*a* $x_1 * y_2$")},
+			expected: []Node{Italic("a"), Plain(" "), InlineMath("x_1 * y_2")},
+		},
 		{
 			name:     "image then formatting in same line",
 			input:    []Node{Plain("![alt](img.png) then `code`")},
@@ -342,3 +514,88 @@ func TestNodeParser(t *testing.T) {
 		})
 	}
 }
+
+func TestMathParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Node
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: []Node{},
+		},
+		{
+			name:     "plain text only",
+			input:    "hello world",
+			expected: []Node{Plain("hello world")},
+		},
+		{
+			name:     "dollar formula",
+			input:    "energy $E = mc^2$ here",
+			expected: []Node{Plain("energy "), InlineMath("E = mc^2"), Plain(" here")},
+		},
+		{
+			name:     "parenthesis formula",
+			input:    "\\(a_1 + a_2\\)",
+			expected: []Node{InlineMath("a_1 + a_2")},
+		},
+		{
+			name:     "multiple formulas",
+			input:    "$x$ and $y$",
+			expected: []Node{InlineMath("x"), Plain(" and "), InlineMath("y")},
+		},
+		{
+			name:     "prices are not math",
+			input:    "costs $5 and $10",
+			expected: []Node{Plain("costs $5 and $10")},
+		},
+		{
+			name:     "space after opening dollar",
+			input:    "a $ b$ c",
+			expected: []Node{Plain("a $ b$ c")},
+		},
+		{
+			name:     "digit after closing dollar",
+			input:    "$x$1",
+			expected: []Node{Plain("$x$1")},
+		},
+		{
+			name:     "escaped dollar",
+			input:    "\\$x$",
+			expected: []Node{Plain("\\$x$")},
+		},
+		{
+			name:     "unclosed formula",
+			input:    "$x",
+			expected: []Node{Plain("$x")},
+		},
+		{
+			name:     "inside code span",
+			input:    "`$x$` and $y$",
+			expected: []Node{Plain("`$x$` and "), InlineMath("y")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MathParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("MathParser(%q)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestMathDelimiters(t *testing.T) {
+	defaultDelimiters := InlineMathDelimiters
+	defer func() { InlineMathDelimiters = defaultDelimiters }()
+
+	InlineMathDelimiters = []MathDelimiter{{"\\(", "\\)"}}
+	got := MathParser("$x$ and \\(y\\)")
+	expected := []Node{Plain("$x$ and "), InlineMath("y")}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("MathParser with custom delimiters\n  got:      %v\n  expected: %v\n  Diff:      %s", got, expected, diff)
+	}
+}
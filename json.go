@@ -0,0 +1,670 @@
+package markdownrenderer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONSCHEMAVERSION is the version of the JSON schema of nodes written by
+// EncodeJSON. It changes whenever a change to the schema would keep older
+// documents from decoding into the same nodes.
+const JSONSCHEMAVERSION = 1
+
+// jsonDocument is the envelope of documents encoded by EncodeJSON
+type jsonDocument struct {
+	Version  int      `json:"version"`
+	Document jsonNode `json:"document"`
+}
+
+// jsonNode is the JSON form of every node. Type tells the node type apart,
+// and only the fields of that type are set.
+type jsonNode struct {
+	Type       NodeKind       `json:"type"`
+	Text       string         `json:"text,omitempty"`
+	Level      int            `json:"level,omitempty"`
+	Link       string         `json:"link,omitempty"`
+	Path       string         `json:"path,omitempty"`
+	Page       string         `json:"page,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	Character  string         `json:"character,omitempty"`
+	Label      string         `json:"label,omitempty"`
+	Kind       string         `json:"kind,omitempty"`
+	Number     int            `json:"number,omitempty"`
+	Attributes Attributes     `json:"attributes,omitempty"`
+	Children   []jsonNode     `json:"children,omitempty"`
+	Header     [][]jsonNode   `json:"header,omitempty"`
+	Rows       [][][]jsonNode `json:"rows,omitempty"`
+	Range      *Range         `json:"range,omitempty"`
+}
+
+// EncodeJSON encodes node, usually a Document, along with the version of
+// the schema
+func EncodeJSON(node Node) ([]byte, error) {
+	j, err := toJSON(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonDocument{Version: JSONSCHEMAVERSION, Document: j})
+}
+
+// DecodeJSON decodes a node encoded by EncodeJSON or EncodeElementJSON
+func DecodeJSON(data []byte) (Node, error) {
+	document, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return fromJSON(document.Document)
+}
+
+// EncodeElementJSON encodes the tree rooted at e, usually as returned by
+// Parse, like EncodeJSON does its node, with the range of every element
+func EncodeElementJSON(e *Element) ([]byte, error) {
+	j, err := elementToJSON(e)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonDocument{Version: JSONSCHEMAVERSION, Document: j})
+}
+
+// DecodeElementJSON decodes a tree encoded by EncodeElementJSON, ranges
+// included. Nodes encoded by EncodeJSON decode to elements without ranges.
+func DecodeElementJSON(data []byte) (*Element, error) {
+	document, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return elementFromJSON(document.Document)
+}
+
+func decodeDocument(data []byte) (jsonDocument, error) {
+	var document jsonDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return document, err
+	}
+	if document.Version != JSONSCHEMAVERSION {
+		return document, fmt.Errorf("unsupported JSON schema version %d", document.Version)
+	}
+	return document, nil
+}
+
+func toJSON(node Node) (jsonNode, error) {
+	j, err := fieldsToJSON(node)
+	if err != nil {
+		return j, err
+	}
+	// the cells of tables are fields rather than children
+	if node.Kind() == TABLENODE {
+		return j, nil
+	}
+	for _, child := range node.Children() {
+		c, err := toJSON(child)
+		if err != nil {
+			return j, err
+		}
+		j.Children = append(j.Children, c)
+	}
+
+	return j, nil
+}
+
+func elementToJSON(e *Element) (jsonNode, error) {
+	j, err := fieldsToJSON(e.Node)
+	if err != nil {
+		return j, err
+	}
+	r := e.Range
+	j.Range = &r
+	if e.Kind() == TABLENODE {
+		return j, nil
+	}
+	for _, child := range e.Children {
+		c, err := elementToJSON(child)
+		if err != nil {
+			return j, err
+		}
+		j.Children = append(j.Children, c)
+	}
+
+	return j, nil
+}
+
+// fieldsToJSON returns the JSON form of node, children aside
+func fieldsToJSON(node Node) (jsonNode, error) {
+	j := jsonNode{Type: node.Kind()}
+	switch v := node.(type) {
+	case Plain:
+		j.Text = string(v)
+	case Bold:
+		j.Text = string(v)
+	case Italic:
+		j.Text = string(v)
+	case Underline:
+		j.Text = string(v)
+	case InlineCode:
+		j.Text = string(v)
+	case Crossed:
+		j.Text = string(v)
+	case InlineMath:
+		j.Text = string(v)
+	case Highlight:
+		j.Text = string(v)
+	case Superscript:
+		j.Text = string(v)
+	case Subscript:
+		j.Text = string(v)
+	case Inserted:
+		j.Text = string(v)
+	case Code:
+		j.Text = string(v)
+	case Hyperlink:
+		j.Link, j.Attributes = v.Link, v.Attributes
+	case Image:
+		j.Path, j.Title, j.Attributes = v.Path, v.Title, v.Attributes
+	case Abbreviation:
+		j.Text, j.Title = v.Text, v.Title
+	case Emoji:
+		j.Name, j.Character = v.Name, v.Character
+	case CrossReference:
+		j.Label = v.Label
+	case WikiLink:
+		j.Page = v.Page
+	case Header:
+		j.Level, j.Attributes = v.Level, v.Attributes
+	case Paragraph:
+		j.Attributes = v.Attributes
+	case DisplayMath:
+		j.Text, j.Number, j.Attributes = v.Text, v.Number, v.Attributes
+	case Admonition:
+		j.Kind = v.Type
+	case Figure:
+		j.Number, j.Attributes = v.Number, v.Attributes
+	case Table:
+		j.Attributes = v.Attributes
+		var err error
+		if j.Header, err = cellsToJSON(v.Header); err != nil {
+			return j, err
+		}
+		for _, row := range v.Rows {
+			cells, err := cellsToJSON(row)
+			if err != nil {
+				return j, err
+			}
+			j.Rows = append(j.Rows, cells)
+		}
+	case Document, Quote, Break, OrderedList, UnorderedList, OrderedItem, UnorderedItem,
+		DefinitionList, DefinitionEntry, DefinitionTerm, DefinitionDescription, AdmonitionTitle, FigureCaption:
+		// nothing but children
+	default:
+		return j, fmt.Errorf("unsupported node type %T", node)
+	}
+
+	return j, nil
+}
+
+func cellsToJSON[T ~[]TableItem](cells T) ([][]jsonNode, error) {
+	var j [][]jsonNode
+	for _, cell := range cells {
+		nodes := []jsonNode{}
+		for _, n := range cell {
+			c, err := toJSON(n)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, c)
+		}
+		j = append(j, nodes)
+	}
+	return j, nil
+}
+
+func fromJSON(j jsonNode) (Node, error) {
+	var children []Node
+	for _, c := range j.Children {
+		child, err := fromJSON(c)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	return nodeFromJSON(j, children)
+}
+
+func elementFromJSON(j jsonNode) (*Element, error) {
+	var children []*Element
+	var nodes []Node
+	for _, c := range j.Children {
+		child, err := elementFromJSON(c)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+		nodes = append(nodes, child.Node)
+	}
+	node, err := nodeFromJSON(j, nodes)
+	if err != nil {
+		return nil, err
+	}
+	r := Range{}
+	if j.Range != nil {
+		r = *j.Range
+	}
+	if table, isT := node.(Table); isT {
+		for _, child := range table.Children() {
+			children = append(children, NewElement(child))
+		}
+	}
+
+	return newElement(node, r, children), nil
+}
+
+// nodeFromJSON returns the node of j, holding children
+func nodeFromJSON(j jsonNode, children []Node) (Node, error) {
+	var node Node
+	switch j.Type {
+	case PLAINNODE:
+		node = Plain(j.Text)
+	case BOLDNODE:
+		node = Bold(j.Text)
+	case ITALICNODE:
+		node = Italic(j.Text)
+	case UNDERLINENODE:
+		node = Underline(j.Text)
+	case INLINECODENODE:
+		node = InlineCode(j.Text)
+	case CROSSEDNODE:
+		node = Crossed(j.Text)
+	case INLINEMATHNODE:
+		node = InlineMath(j.Text)
+	case HIGHLIGHTNODE:
+		node = Highlight(j.Text)
+	case SUPERSCRIPTNODE:
+		node = Superscript(j.Text)
+	case SUBSCRIPTNODE:
+		node = Subscript(j.Text)
+	case INSERTEDNODE:
+		node = Inserted(j.Text)
+	case CODENODE:
+		node = Code(j.Text)
+	case HYPERLINKNODE:
+		node = Hyperlink{Link: j.Link, Attributes: j.Attributes}
+	case IMAGENODE:
+		node = Image{Path: j.Path, Title: j.Title, Attributes: j.Attributes}
+	case ABBREVIATIONNODE:
+		node = Abbreviation{Text: j.Text, Title: j.Title}
+	case EMOJINODE:
+		node = Emoji{Name: j.Name, Character: j.Character}
+	case CROSSREFERENCENODE:
+		node = CrossReference{Label: j.Label}
+	case WIKILINKNODE:
+		node = WikiLink{Page: j.Page}
+	case HEADERNODE:
+		node = Header{Level: j.Level, Attributes: j.Attributes}
+	case PARAGRAPHNODE:
+		node = Paragraph{Attributes: j.Attributes}
+	case DISPLAYMATHNODE:
+		node = DisplayMath{Text: j.Text, Number: j.Number, Attributes: j.Attributes}
+	case BREAKNODE:
+		node = Break(true)
+	case ADMONITIONNODE:
+		node = Admonition{Type: j.Kind}
+	case FIGURENODE:
+		node = Figure{Number: j.Number, Attributes: j.Attributes}
+	case TABLENODE:
+		table := Table{Attributes: j.Attributes}
+		header, err := cellsFromJSON(j.Header)
+		if err != nil {
+			return nil, err
+		}
+		table.Header = TableHeader(header)
+		for _, r := range j.Rows {
+			row, err := cellsFromJSON(r)
+			if err != nil {
+				return nil, err
+			}
+			table.Rows = append(table.Rows, TableRow(row))
+		}
+		return table, nil
+	case DOCUMENTNODE:
+		node = Document{}
+	case QUOTENODE:
+		node = Quote{}
+	case ORDEREDLISTNODE:
+		node = OrderedList{}
+	case UNORDEREDLISTNODE:
+		node = UnorderedList{}
+	case ORDEREDITEMNODE:
+		node = OrderedItem{}
+	case UNORDEREDITEMNODE:
+		node = UnorderedItem{}
+	case DEFINITIONLISTNODE:
+		node = DefinitionList{}
+	case DEFINITIONENTRYNODE:
+		node = DefinitionEntry{}
+	case DEFINITIONTERMNODE:
+		node = DefinitionTerm{}
+	case DEFINITIONDESCRIPTIONNODE:
+		node = DefinitionDescription{}
+	case ADMONITIONTITLENODE:
+		node = AdmonitionTitle{}
+	case FIGURECAPTIONNODE:
+		node = FigureCaption{}
+	default:
+		return nil, fmt.Errorf("unknown node type %q", j.Type)
+	}
+	if err := checkJSON(j, children); err != nil {
+		return nil, err
+	}
+	if len(children) == 0 {
+		return node, nil
+	}
+
+	node = withChildren(node, children)
+	// withChildren drops the children a node cannot hold, such as
+	// paragraphs in a list or any child of a leaf
+	if len(node.Children()) != len(children) {
+		return nil, fmt.Errorf("%q node with unexpected children", j.Type)
+	}
+	return node, nil
+}
+
+// checkJSON rejects the nodes the parser never produces and that would
+// render invalid HTML, such as headers of level 0 or figures without an
+// image
+func checkJSON(j jsonNode, children []Node) error {
+	has := func(kind NodeKind) bool {
+		for _, c := range children {
+			if c.Kind() == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch j.Type {
+	case HEADERNODE:
+		if j.Level < 1 || j.Level > 6 {
+			return fmt.Errorf("header level %d is not between 1 and 6", j.Level)
+		}
+	case FIGURENODE:
+		if !has(IMAGENODE) {
+			return fmt.Errorf("figure without an image")
+		}
+	case DEFINITIONENTRYNODE:
+		if !has(DEFINITIONTERMNODE) || !has(DEFINITIONDESCRIPTIONNODE) {
+			return fmt.Errorf("definition entry without a term or a description")
+		}
+	case ADMONITIONNODE:
+		if len(j.Kind) == 0 {
+			return fmt.Errorf("admonition without a kind")
+		}
+	}
+	return nil
+}
+
+func cellsFromJSON(j [][]jsonNode) ([]TableItem, error) {
+	var cells []TableItem
+	for _, c := range j {
+		cell := TableItem{}
+		for _, n := range c {
+			node, err := fromJSON(n)
+			if err != nil {
+				return nil, err
+			}
+			cell = append(cell, node)
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
+
+func marshalNode(node Node) ([]byte, error) {
+	j, err := toJSON(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+func unmarshalNode[T Node](data []byte, target *T) error {
+	var j jsonNode
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	node, err := fromJSON(j)
+	if err != nil {
+		return err
+	}
+	typed, isT := node.(T)
+	if !isT {
+		return fmt.Errorf("cannot decode a %q node into %T", j.Type, *target)
+	}
+	*target = typed
+	return nil
+}
+
+// Nodes marshal to the JSON schema of EncodeJSON, without the version
+
+func (t Plain) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Plain) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Bold) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Bold) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Italic) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Italic) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Underline) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Underline) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t InlineCode) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *InlineCode) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Crossed) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Crossed) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t InlineMath) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *InlineMath) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Highlight) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Highlight) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Superscript) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Superscript) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Subscript) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Subscript) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Inserted) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Inserted) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Hyperlink) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Hyperlink) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Image) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Image) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Abbreviation) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Abbreviation) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t Emoji) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *Emoji) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t CrossReference) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *CrossReference) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (t WikiLink) MarshalJSON() ([]byte, error) {
+	return marshalNode(t)
+}
+func (t *WikiLink) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, t)
+}
+func (b Document) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Document) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Header) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Header) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Paragraph) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Paragraph) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Code) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Code) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b DisplayMath) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *DisplayMath) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Quote) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Quote) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Break) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Break) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b OrderedList) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *OrderedList) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b UnorderedList) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *UnorderedList) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b OrderedItem) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *OrderedItem) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b UnorderedItem) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *UnorderedItem) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Table) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Table) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b DefinitionList) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *DefinitionList) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b DefinitionEntry) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *DefinitionEntry) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b DefinitionTerm) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *DefinitionTerm) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b DefinitionDescription) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *DefinitionDescription) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Admonition) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Admonition) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b AdmonitionTitle) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *AdmonitionTitle) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b Figure) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *Figure) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
+func (b FigureCaption) MarshalJSON() ([]byte, error) {
+	return marshalNode(b)
+}
+func (b *FigureCaption) UnmarshalJSON(data []byte) error {
+	return unmarshalNode(data, b)
+}
@@ -0,0 +1,162 @@
+package markdownrenderer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	inputs := []string{
+		"# **Bold** Title {#top .lead}\n\nSome *text* with `code`, ~~old~~ and $x^2$.",
+		"A [link](x.com){rel=me} and ![alt](a.png \"Title\") and [[Wiki Page|wiki]] :tada:",
+		"> quoted\n\n---\n\n```\ncode\n```\n\n$$\nx\n$$ {#eq:x}",
+		"1. one\n2. **two**\n\n* a\n* b",
+		"Apple\nPomme\n: A fruit\n: A company",
+		":::note\nRead *this*\n:::\n\n:::warning Careful\nfirst\n:::",
+		"See @fig:a and @sec:none.\n\n![First](a.png){#fig:a}",
+		"*[HTML]: Hyper Text Markup Language\n\nSome HTML here",
+	}
+
+	defer func() {
+		Extensions = 0
+	}()
+	Extensions = EXTFIGURES | EXTCROSSREFERENCES
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			node := Parse(input).Build()
+			data, err := EncodeJSON(node)
+			if err != nil {
+				t.Fatalf("EncodeJSON(%v) error: %v", node, err)
+			}
+			got, err := DecodeJSON(data)
+			if err != nil {
+				t.Fatalf("DecodeJSON(%s) error: %v", data, err)
+			}
+			if diff := cmp.Diff(got, node, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("DecodeJSON(EncodeJSON(%v))\n  got:      %v\n  expected: %v\n  Diff:     %s", node, got, node, diff)
+			}
+		})
+	}
+}
+
+func TestElementJSONRoundTrip(t *testing.T) {
+	input := "# Title {#t}\n\n> A [*link*](x.com) and :tada:\n> more"
+	root := Parse(input)
+	data, err := EncodeElementJSON(root)
+	if err != nil {
+		t.Fatalf("EncodeElementJSON(Parse(%q)) error: %v", input, err)
+	}
+	got, err := DecodeElementJSON(data)
+	if err != nil {
+		t.Fatalf("DecodeElementJSON(%s) error: %v", data, err)
+	}
+	ranges := func(e *Element) []Range {
+		found := []Range{}
+		Walk(e, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+			found = append(found, e.Range)
+			return WALKCONTINUE
+		}})
+		return found
+	}
+	if diff := cmp.Diff(ranges(got), ranges(root)); diff != "" {
+		t.Errorf("DecodeElementJSON(EncodeElementJSON(Parse(%q))) ranges\n  Diff: %s", input, diff)
+	}
+	if diff := cmp.Diff(got.Build(), root.Build(), cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("DecodeElementJSON(EncodeElementJSON(Parse(%q))) nodes\n  Diff: %s", input, diff)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Node
+		expected string
+	}{
+		{
+			name:     "leaf",
+			input:    Bold("b"),
+			expected: `{"type":"bold","text":"b"}`,
+		},
+		{
+			name:     "header",
+			input:    Header{Level: 2, Content: []Node{Plain("a "), Emoji{Name: "tada", Character: "🎉"}}, Attributes: Attributes{"id": "h"}},
+			expected: `{"type":"header","level":2,"attributes":{"id":"h"},"children":[{"type":"plain","text":"a "},{"type":"emoji","name":"tada","character":"🎉"}]}`,
+		},
+		{
+			name:     "admonition",
+			input:    Admonition{Type: "tip", Title: AdmonitionTitle{Plain("t")}, Content: []Node{Break(true)}},
+			expected: `{"type":"admonition","kind":"tip","children":[{"type":"admonition_title","children":[{"type":"plain","text":"t"}]},{"type":"break"}]}`,
+		},
+		{
+			name:     "table",
+			input:    Table{Header: TableHeader{{Plain("h")}}, Rows: []TableRow{{{Plain("c"), Italic("d")}}}},
+			expected: `{"type":"table","header":[[{"type":"plain","text":"h"}]],"rows":[[[{"type":"plain","text":"c"},{"type":"italic","text":"d"}]]]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("json.Marshal(%v) error: %v", tt.input, err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("json.Marshal(%v)\n  got:      %s\n  expected: %s", tt.input, data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONUnmarshalTyped(t *testing.T) {
+	var decoded struct {
+		List  UnorderedList
+		Table Table
+	}
+	data := `{"List":{"type":"unordered_list","children":[{"type":"unordered_item","children":[{"type":"plain","text":"a"}]}]},"Table":{"type":"table","header":[[{"type":"plain","text":"h"}]]}}`
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error: %v", data, err)
+	}
+	expected := UnorderedList{UnorderedItem{Plain("a")}}
+	if diff := cmp.Diff(decoded.List, expected); diff != "" {
+		t.Errorf("json.Unmarshal(%s)\n  got:      %v\n  expected: %v\n  Diff:     %s", data, decoded.List, expected, diff)
+	}
+	expectedTable := Table{Header: TableHeader{{Plain("h")}}}
+	if diff := cmp.Diff(decoded.Table, expectedTable); diff != "" {
+		t.Errorf("json.Unmarshal(%s)\n  got:      %v\n  expected: %v\n  Diff:     %s", data, decoded.Table, expectedTable, diff)
+	}
+
+	var header Header
+	if err := json.Unmarshal([]byte(`{"type":"paragraph"}`), &header); err == nil {
+		t.Errorf("json.Unmarshal of a paragraph into a Header succeeded, expected an error")
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "invalid", input: `{"version":`},
+		{name: "unsupported version", input: `{"version":2,"document":{"type":"document"}}`},
+		{name: "missing version", input: `{"document":{"type":"document"}}`},
+		{name: "unknown type", input: `{"version":1,"document":{"type":"document","children":[{"type":"marquee"}]}}`},
+		{name: "header level 0", input: `{"version":1,"document":{"type":"header","level":0}}`},
+		{name: "header level 7", input: `{"version":1,"document":{"type":"header","level":7,"children":[{"type":"plain","text":"h"}]}}`},
+		{name: "figure without image", input: `{"version":1,"document":{"type":"figure","children":[{"type":"figure_caption","children":[{"type":"plain","text":"c"}]}]}}`},
+		{name: "empty figure", input: `{"version":1,"document":{"type":"figure"}}`},
+		{name: "figure with two images", input: `{"version":1,"document":{"type":"figure","children":[{"type":"image","path":"a.png"},{"type":"image","path":"b.png"}]}}`},
+		{name: "definition entry without description", input: `{"version":1,"document":{"type":"definition_entry","children":[{"type":"definition_term"}]}}`},
+		{name: "admonition without kind", input: `{"version":1,"document":{"type":"admonition"}}`},
+		{name: "paragraph in list", input: `{"version":1,"document":{"type":"unordered_list","children":[{"type":"paragraph"}]}}`},
+		{name: "children of a leaf", input: `{"version":1,"document":{"type":"plain","text":"a","children":[{"type":"bold","text":"b"}]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if node, err := DecodeJSON([]byte(tt.input)); err == nil {
+				t.Errorf("DecodeJSON(%s) = %v, expected an error", tt.input, node)
+			}
+		})
+	}
+}
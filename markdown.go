@@ -1,7 +1,11 @@
 package markdownrenderer
 
+// Node is an element of the parsed Markdown. Kind names its type, and
+// Children returns the nodes it contains, in document order.
 type Node interface {
 	ToHTML() HTMLNode
+	Kind() NodeKind
+	Children() []Node
 }
 
 func markdownToHTML(nodes []Node) []HTMLNode {
@@ -14,6 +18,67 @@ func markdownToHTML(nodes []Node) []HTMLNode {
 	return htmlNodes
 }
 
+// mapInlines replaces every inline node with the result of f, descending into
+// the blocks that contain them. Links and images count as inline nodes, and
+// blocks without inline content, like code, are passed to f as well.
+func mapInlines(nodes []Node, f func(Node) []Node) []Node {
+	if nodes == nil {
+		return nil
+	}
+	newNodes := []Node{}
+	for _, n := range nodes {
+		newNodes = append(newNodes, mapInline(n, f)...)
+	}
+	return newNodes
+}
+
+func mapInline(n Node, f func(Node) []Node) []Node {
+	switch v := n.(type) {
+	case Paragraph:
+		v.Content = mapInlines(v.Content, f)
+		return []Node{v}
+	case Header:
+		v.Content = mapInlines(v.Content, f)
+		return []Node{v}
+	case Quote:
+		return []Node{Quote(mapInlines(v, f))}
+	case UnorderedList:
+		list := UnorderedList{}
+		for _, item := range v {
+			list = append(list, UnorderedItem(mapInlines(item, f)))
+		}
+		return []Node{list}
+	case OrderedList:
+		list := OrderedList{}
+		for _, item := range v {
+			list = append(list, OrderedItem(mapInlines(item, f)))
+		}
+		return []Node{list}
+	case DefinitionList:
+		list := DefinitionList{}
+		for _, entry := range v {
+			newEntry := DefinitionEntry{}
+			for _, term := range entry.Terms {
+				newEntry.Terms = append(newEntry.Terms, DefinitionTerm(mapInlines(term, f)))
+			}
+			for _, desc := range entry.Descriptions {
+				newEntry.Descriptions = append(newEntry.Descriptions, DefinitionDescription(mapInlines(desc, f)))
+			}
+			list = append(list, newEntry)
+		}
+		return []Node{list}
+	case Admonition:
+		v.Title = mapInlines(v.Title, f)
+		v.Content = mapInlines(v.Content, f)
+		return []Node{v}
+	case Figure:
+		v.Caption = mapInlines(v.Caption, f)
+		return []Node{v}
+	default:
+		return f(v)
+	}
+}
+
 // Leaves
 
 type Plain string
@@ -22,48 +87,216 @@ type Italic string
 type Underline string
 type InlineCode string
 type Crossed string
+type InlineMath string
+type Highlight string
+type Superscript string
+type Subscript string
+type Inserted string
 type Hyperlink struct {
-	Content []Node
-	Link    string
+	Content    []Node
+	Link       string
+	Attributes Attributes
 }
 type Image struct {
+	Content    []Node
+	Path       string
+	Title      string
+	Attributes Attributes
+}
+type Abbreviation struct {
+	Text  string
+	Title string
+}
+type Emoji struct {
+	Name      string
+	Character string
+}
+type CrossReference struct {
+	Label   string
+	Content []Node
+}
+type WikiLink struct {
 	Content []Node
-	Path    string
+	Page    string
 }
 
 func (t Plain) ToHTML() HTMLNode {
 	return HTMLPlain(t)
 }
 func (t Bold) ToHTML() HTMLNode {
-	return HTMLBold(t)
+	return HTMLBold{Text: string(t)}
 }
 func (t Italic) ToHTML() HTMLNode {
-	return HTMLItalic(t)
+	return HTMLItalic{Text: string(t)}
 }
 func (t Underline) ToHTML() HTMLNode {
-	return HTMLUnderline(t)
+	return HTMLUnderline{Text: string(t)}
 }
 func (t InlineCode) ToHTML() HTMLNode {
-	return HTMLInlineCode(t)
+	return HTMLInlineCode{Text: string(t)}
 }
 func (t Crossed) ToHTML() HTMLNode {
-	return HTMLCrossed(t)
+	return HTMLCrossed{Text: string(t)}
+}
+func (t Highlight) ToHTML() HTMLNode {
+	return HTMLHighlight{Text: string(t)}
+}
+func (t Superscript) ToHTML() HTMLNode {
+	return HTMLSuperscript{Text: string(t)}
+}
+func (t Subscript) ToHTML() HTMLNode {
+	return HTMLSubscript{Text: string(t)}
+}
+func (t Inserted) ToHTML() HTMLNode {
+	return HTMLInserted{Text: string(t)}
+}
+func (t InlineMath) ToHTML() HTMLNode {
+	return HTMLInlineMath{Text: string(t)}
 }
 func (t Hyperlink) ToHTML() HTMLNode {
-	return HTMLHyperlink{Content: markdownToHTML(t.Content), Link: t.Link}
+	return HTMLHyperlink{Content: markdownToHTML(t.Content), Link: t.Link, Attributes: t.Attributes.Clone()}
 }
 func (t Image) ToHTML() HTMLNode {
-	return HTMLImage{Content: markdownToHTML(t.Content), Path: t.Path}
+	return HTMLImage{Content: markdownToHTML(t.Content), Path: t.Path, Title: t.Title, Attributes: t.Attributes.Clone()}
+}
+func (t Abbreviation) ToHTML() HTMLNode {
+	return HTMLAbbreviation{Text: t.Text, Title: t.Title}
+}
+func (t Emoji) ToHTML() HTMLNode {
+	return HTMLEmoji{Name: t.Name, Character: t.Character}
+}
+func (t CrossReference) ToHTML() HTMLNode {
+	// unresolved references keep their source text
+	if len(t.Content) == 0 {
+		return HTMLPlain(CROSSREFERENCEPREFIX + t.Label)
+	}
+	return HTMLHyperlink{Content: markdownToHTML(t.Content), Link: "#" + t.Label, Attributes: Attributes{"class": "cross-reference"}}
+}
+func (t WikiLink) ToHTML() HTMLNode {
+	link, exists := resolveWikiLink(WikiResolver, t.Page)
+	return HTMLWikiLink{Content: markdownToHTML(t.Content), Link: link, Exists: exists}
+}
+
+func (t Plain) Kind() NodeKind {
+	return PLAINNODE
+}
+func (t Bold) Kind() NodeKind {
+	return BOLDNODE
+}
+func (t Italic) Kind() NodeKind {
+	return ITALICNODE
+}
+func (t Underline) Kind() NodeKind {
+	return UNDERLINENODE
+}
+func (t InlineCode) Kind() NodeKind {
+	return INLINECODENODE
+}
+func (t Crossed) Kind() NodeKind {
+	return CROSSEDNODE
+}
+func (t Highlight) Kind() NodeKind {
+	return HIGHLIGHTNODE
+}
+func (t Superscript) Kind() NodeKind {
+	return SUPERSCRIPTNODE
+}
+func (t Subscript) Kind() NodeKind {
+	return SUBSCRIPTNODE
+}
+func (t Inserted) Kind() NodeKind {
+	return INSERTEDNODE
+}
+func (t InlineMath) Kind() NodeKind {
+	return INLINEMATHNODE
+}
+func (t Hyperlink) Kind() NodeKind {
+	return HYPERLINKNODE
+}
+func (t Image) Kind() NodeKind {
+	return IMAGENODE
+}
+func (t Abbreviation) Kind() NodeKind {
+	return ABBREVIATIONNODE
+}
+func (t Emoji) Kind() NodeKind {
+	return EMOJINODE
+}
+func (t CrossReference) Kind() NodeKind {
+	return CROSSREFERENCENODE
+}
+func (t WikiLink) Kind() NodeKind {
+	return WIKILINKNODE
+}
+func (t Plain) Children() []Node {
+	return nil
+}
+func (t Bold) Children() []Node {
+	return nil
+}
+func (t Italic) Children() []Node {
+	return nil
+}
+func (t Underline) Children() []Node {
+	return nil
+}
+func (t InlineCode) Children() []Node {
+	return nil
+}
+func (t Crossed) Children() []Node {
+	return nil
+}
+func (t Highlight) Children() []Node {
+	return nil
+}
+func (t Superscript) Children() []Node {
+	return nil
+}
+func (t Subscript) Children() []Node {
+	return nil
+}
+func (t Inserted) Children() []Node {
+	return nil
+}
+func (t InlineMath) Children() []Node {
+	return nil
+}
+func (t Hyperlink) Children() []Node {
+	return t.Content
+}
+func (t Image) Children() []Node {
+	return t.Content
+}
+func (t Abbreviation) Children() []Node {
+	return nil
+}
+func (t Emoji) Children() []Node {
+	return nil
+}
+func (t CrossReference) Children() []Node {
+	return t.Content
+}
+func (t WikiLink) Children() []Node {
+	return t.Content
 }
 
 // Containers
 
 type Header struct {
-	Content []Node
-	Level   int
+	Content    []Node
+	Level      int
+	Attributes Attributes
+}
+type Paragraph struct {
+	Content    []Node
+	Attributes Attributes
 }
-type Paragraph []Node
 type Code string
+type DisplayMath struct {
+	Text       string
+	Number     int
+	Attributes Attributes
+}
 type Quote []Node
 type Break bool
 
@@ -77,43 +310,305 @@ type TableItem []Node
 type TableHeader []TableItem
 type TableRow []TableItem
 type Table struct {
-	Header TableHeader
-	Rows   []TableRow
+	Header     TableHeader
+	Rows       []TableRow
+	Attributes Attributes
+}
+
+type DefinitionTerm []Node
+type DefinitionDescription []Node
+type DefinitionEntry struct {
+	Terms        []DefinitionTerm
+	Descriptions []DefinitionDescription
+}
+type DefinitionList []DefinitionEntry
+
+type AdmonitionTitle []Node
+type Admonition struct {
+	Type    string
+	Title   AdmonitionTitle
+	Content []Node
+}
+
+type FigureCaption []Node
+type Figure struct {
+	Image      Image
+	Caption    FigureCaption
+	Number     int
+	Attributes Attributes
 }
 
 func (b Header) ToHTML() HTMLNode {
-	return HTMLHeader{Level: b.Level, Content: markdownToHTML(b.Content)}
+	return HTMLHeader{Level: b.Level, Content: markdownToHTML(b.Content), Attributes: b.Attributes.Clone()}
 }
 func (b Paragraph) ToHTML() HTMLNode {
-	return HTMLParagraph(markdownToHTML(b))
+	return HTMLParagraph{Content: markdownToHTML(b.Content), Attributes: b.Attributes.Clone()}
 }
 func (b Code) ToHTML() HTMLNode {
-	return HTMLCode(b)
+	return HTMLCode{Text: string(b)}
+}
+func (b DisplayMath) ToHTML() HTMLNode {
+	return HTMLDisplayMath{Text: b.Text, Number: b.Number, Attributes: b.Attributes.Clone()}
 }
 func (b Quote) ToHTML() HTMLNode {
-	return HTMLQuote(markdownToHTML(b))
+	return HTMLQuote{Content: markdownToHTML(b)}
 }
 func (b Break) ToHTML() HTMLNode {
-	return HTMLBreak(b)
+	return HTMLBreak{}
 }
 func (b OrderedList) ToHTML() HTMLNode {
 	htmlItems := []HTMLOrderedItem{}
 	for _, item := range b {
-		htmlItem := markdownToHTML(item)
-		htmlItems = append(htmlItems, htmlItem)
+		htmlItems = append(htmlItems, item.ToHTML().(HTMLOrderedItem))
 	}
-	return HTMLOrderedList(htmlItems)
+	return HTMLOrderedList{Items: htmlItems}
 }
 func (b UnorderedList) ToHTML() HTMLNode {
 	htmlItems := []HTMLUnorderedItem{}
 	for _, item := range b {
-		htmlItem := markdownToHTML(item)
-		htmlItems = append(htmlItems, htmlItem)
+		htmlItems = append(htmlItems, item.ToHTML().(HTMLUnorderedItem))
 	}
-	return HTMLUnorderedList(htmlItems)
+	return HTMLUnorderedList{Items: htmlItems}
+}
+func (b OrderedItem) ToHTML() HTMLNode {
+	return HTMLOrderedItem{Content: markdownToHTML(b)}
+}
+func (b UnorderedItem) ToHTML() HTMLNode {
+	return HTMLUnorderedItem{Content: markdownToHTML(b)}
+}
+
+func (b DefinitionTerm) ToHTML() HTMLNode {
+	return HTMLDefinitionTerm{Content: markdownToHTML(b)}
+}
+func (b DefinitionDescription) ToHTML() HTMLNode {
+	return HTMLDefinitionDescription{Content: markdownToHTML(b)}
+}
+func (b DefinitionList) ToHTML() HTMLNode {
+	htmlEntries := []HTMLDefinitionEntry{}
+	for _, entry := range b {
+		htmlEntries = append(htmlEntries, entry.ToHTML().(HTMLDefinitionEntry))
+	}
+	return HTMLDefinitionList{Entries: htmlEntries}
+}
+func (b DefinitionEntry) ToHTML() HTMLNode {
+	htmlEntry := HTMLDefinitionEntry{}
+	for _, term := range b.Terms {
+		htmlEntry.Terms = append(htmlEntry.Terms, term.ToHTML().(HTMLDefinitionTerm))
+	}
+	for _, desc := range b.Descriptions {
+		htmlEntry.Descriptions = append(htmlEntry.Descriptions, desc.ToHTML().(HTMLDefinitionDescription))
+	}
+	return htmlEntry
+}
+func (b Admonition) ToHTML() HTMLNode {
+	return HTMLAdmonition{Type: b.Type, Title: markdownToHTML(b.Title), Content: markdownToHTML(b.Content)}
+}
+func (b AdmonitionTitle) ToHTML() HTMLNode {
+	return HTMLAdmonitionTitle{Content: markdownToHTML(b)}
+}
+func (b Figure) ToHTML() HTMLNode {
+	image := b.Image.ToHTML().(HTMLImage)
+	return HTMLFigure{Image: image, Caption: markdownToHTML(b.Caption), Number: b.Number, Attributes: b.Attributes.Clone()}
+}
+func (b FigureCaption) ToHTML() HTMLNode {
+	return HTMLFigureCaption{Content: markdownToHTML(b)}
 }
 
-// TODO: to implement
 func (b Table) ToHTML() HTMLNode {
+	table := HTMLTable{Header: b.Header.ToHTML().(HTMLTableHeader), Attributes: b.Attributes.Clone()}
+	for _, row := range b.Rows {
+		table.Rows = append(table.Rows, row.ToHTML().(HTMLTableRow))
+	}
+	return table
+}
+func (b TableHeader) ToHTML() HTMLNode {
+	header := HTMLTableHeader{}
+	for _, cell := range b {
+		header = append(header, cell.ToHTML().(HTMLTableItem))
+	}
+	return header
+}
+func (b TableRow) ToHTML() HTMLNode {
+	row := HTMLTableRow{}
+	for _, cell := range b {
+		row = append(row, cell.ToHTML().(HTMLTableItem))
+	}
+	return row
+}
+func (b TableItem) ToHTML() HTMLNode {
+	return HTMLTableItem(markdownToHTML(b))
+}
+
+func (b Header) Kind() NodeKind {
+	return HEADERNODE
+}
+func (b Paragraph) Kind() NodeKind {
+	return PARAGRAPHNODE
+}
+func (b Code) Kind() NodeKind {
+	return CODENODE
+}
+func (b DisplayMath) Kind() NodeKind {
+	return DISPLAYMATHNODE
+}
+func (b Quote) Kind() NodeKind {
+	return QUOTENODE
+}
+func (b Break) Kind() NodeKind {
+	return BREAKNODE
+}
+func (b OrderedList) Kind() NodeKind {
+	return ORDEREDLISTNODE
+}
+func (b UnorderedList) Kind() NodeKind {
+	return UNORDEREDLISTNODE
+}
+func (b OrderedItem) Kind() NodeKind {
+	return ORDEREDITEMNODE
+}
+func (b UnorderedItem) Kind() NodeKind {
+	return UNORDEREDITEMNODE
+}
+func (b DefinitionTerm) Kind() NodeKind {
+	return DEFINITIONTERMNODE
+}
+func (b DefinitionDescription) Kind() NodeKind {
+	return DEFINITIONDESCRIPTIONNODE
+}
+func (b DefinitionEntry) Kind() NodeKind {
+	return DEFINITIONENTRYNODE
+}
+func (b DefinitionList) Kind() NodeKind {
+	return DEFINITIONLISTNODE
+}
+func (b Admonition) Kind() NodeKind {
+	return ADMONITIONNODE
+}
+func (b AdmonitionTitle) Kind() NodeKind {
+	return ADMONITIONTITLENODE
+}
+func (b Figure) Kind() NodeKind {
+	return FIGURENODE
+}
+func (b FigureCaption) Kind() NodeKind {
+	return FIGURECAPTIONNODE
+}
+func (b Table) Kind() NodeKind {
+	return TABLENODE
+}
+func (b TableHeader) Kind() NodeKind {
+	return TABLEHEADERNODE
+}
+func (b TableRow) Kind() NodeKind {
+	return TABLEROWNODE
+}
+func (b TableItem) Kind() NodeKind {
+	return TABLECELLNODE
+}
+func (b Header) Children() []Node {
+	return b.Content
+}
+func (b Paragraph) Children() []Node {
+	return b.Content
+}
+func (b Code) Children() []Node {
 	return nil
 }
+func (b DisplayMath) Children() []Node {
+	return nil
+}
+func (b Quote) Children() []Node {
+	return b
+}
+func (b Break) Children() []Node {
+	return nil
+}
+func (b OrderedList) Children() []Node {
+	children := make([]Node, 0, len(b))
+	for _, item := range b {
+		children = append(children, item)
+	}
+	return children
+}
+func (b UnorderedList) Children() []Node {
+	children := make([]Node, 0, len(b))
+	for _, item := range b {
+		children = append(children, item)
+	}
+	return children
+}
+func (b OrderedItem) Children() []Node {
+	return b
+}
+func (b UnorderedItem) Children() []Node {
+	return b
+}
+func (b DefinitionTerm) Children() []Node {
+	return b
+}
+func (b DefinitionDescription) Children() []Node {
+	return b
+}
+func (b DefinitionEntry) Children() []Node {
+	children := make([]Node, 0, len(b.Terms)+len(b.Descriptions))
+	for _, term := range b.Terms {
+		children = append(children, term)
+	}
+	for _, desc := range b.Descriptions {
+		children = append(children, desc)
+	}
+	return children
+}
+func (b DefinitionList) Children() []Node {
+	children := make([]Node, 0, len(b))
+	for _, item := range b {
+		children = append(children, item)
+	}
+	return children
+}
+func (b Admonition) Children() []Node {
+	if b.Title == nil {
+		return b.Content
+	}
+	return append([]Node{b.Title}, b.Content...)
+}
+func (b AdmonitionTitle) Children() []Node {
+	return b
+}
+func (b Figure) Children() []Node {
+	if b.Caption == nil {
+		return []Node{b.Image}
+	}
+	return []Node{b.Image, b.Caption}
+}
+func (b FigureCaption) Children() []Node {
+	return b
+}
+
+func (b Table) Children() []Node {
+	children := []Node{}
+	if b.Header != nil {
+		children = append(children, b.Header)
+	}
+	for _, row := range b.Rows {
+		children = append(children, row)
+	}
+	return children
+}
+func (b TableHeader) Children() []Node {
+	children := []Node{}
+	for _, cell := range b {
+		children = append(children, cell)
+	}
+	return children
+}
+func (b TableRow) Children() []Node {
+	children := []Node{}
+	for _, cell := range b {
+		children = append(children, cell)
+	}
+	return children
+}
+func (b TableItem) Children() []Node {
+	return b
+}
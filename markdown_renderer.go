@@ -0,0 +1,451 @@
+package markdownrenderer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HARDBREAK ends a line of text without it being joined to the next one
+// by wrapping
+const HARDBREAK = "  \n"
+
+// MarkdownRenderer renders document trees back to Markdown, normalised: ATX
+// headers, dashes for unordered lists, counted ordered lists, fenced code
+// and admonitions, and aligned tables. Parsing its output gives the same
+// tree, but for the whitespace of text, which wrapping turns into single
+// spaces and line breaks. Hard line breaks, two spaces ending a line, are
+// kept.
+type MarkdownRenderer struct {
+	// Width wraps paragraphs, quotes and definitions at that many columns
+	// when positive. Lines only break at spaces in text, so links and
+	// formatted text longer than Width overflow.
+	Width int
+	// options are those of the converter using the renderer, or nil for the
+	// package-level variables
+	options *Options
+}
+
+func NewMarkdownRenderer(width int) *MarkdownRenderer {
+	return &MarkdownRenderer{Width: width}
+}
+
+func (r *MarkdownRenderer) withOptions(options *Options) Renderer {
+	renderer := *r
+	renderer.options = options
+	return &renderer
+}
+
+func (r *MarkdownRenderer) Render(element *Element) string {
+	options := r.options
+	if options == nil {
+		current := currentOptions()
+		options = &current
+	}
+	w := &markdownWriter{width: r.Width, options: options, abbreviations: map[string]string{}, figures: map[string]string{}}
+	node := element.Build()
+	if document, isD := node.(Document); isD {
+		return w.document(document)
+	}
+	if isInline(node) {
+		return w.inline(node)
+	}
+	return w.block(node, w.width)
+}
+
+// RenderTo writes the Markdown of element to w
+func (r *MarkdownRenderer) RenderTo(w io.Writer, element *Element) error {
+	_, err := io.WriteString(w, r.Render(element))
+	return err
+}
+
+// markdownWriter holds the state of a single rendering
+type markdownWriter struct {
+	width   int
+	options *Options
+	// abbreviations are defined at the end of the document
+	abbreviations map[string]string
+	// figures maps figure labels to their names, which links to them are
+	// given when left empty
+	figures map[string]string
+}
+
+func (w *markdownWriter) document(document Document) string {
+	w.nameFigures(document)
+	blocks := []string{}
+	for _, b := range document {
+		blocks = append(blocks, w.block(b, w.width))
+	}
+	if len(w.abbreviations) > 0 {
+		words := make([]string, 0, len(w.abbreviations))
+		for word := range w.abbreviations {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		definitions := []string{}
+		for _, word := range words {
+			definitions = append(definitions, fmt.Sprintf("*[%s]: %s", word, w.abbreviations[word]))
+		}
+		blocks = append(blocks, strings.Join(definitions, "\n"))
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	return strings.Join(blocks, BLOCKDELIMITER) + "\n"
+}
+
+func (w *markdownWriter) nameFigures(nodes []Node) {
+	for _, n := range nodes {
+		switch v := n.(type) {
+		case Figure:
+			id, isI := v.Attributes["id"]
+			if !isI {
+				continue
+			}
+			if v.Number > 0 {
+				w.figures[id] = w.options.FigureLabel + " " + strconv.Itoa(v.Number)
+			} else {
+				w.figures[id] = w.inlines(v.Caption)
+			}
+		case Admonition:
+			w.nameFigures(v.Content)
+		}
+	}
+}
+
+// block renders a block, wrapping its text at width
+func (w *markdownWriter) block(node Node, width int) string {
+	switch v := node.(type) {
+	case Header:
+		header := strings.Repeat(HEADERPREFIX, v.Level) + " " + w.inlines(v.Content)
+		if attributes := v.Attributes.MarkdownRender(); len(attributes) > 0 {
+			header += " " + attributes
+		}
+		return header
+	case Paragraph:
+		return leadingAttributeLine(v.Attributes) + w.wrap(v.Content, width)
+	case Code:
+		return CODEDELIMITER + string(v) + CODEDELIMITER
+	case DisplayMath:
+		d := w.options.DisplayMathDelimiters[0]
+		math := d.Open + "\n" + v.Text + "\n" + d.Close
+		if attributes := v.Attributes.MarkdownRender(); len(attributes) > 0 {
+			math += " " + attributes
+		}
+		return math
+	case Quote:
+		return prefixLines(w.wrap(v, width-len(QUOTEPREFIX1)), QUOTEPREFIX1, QUOTEPREFIX1)
+	case Break:
+		return BREAKDELIMITER
+	case UnorderedList:
+		items := []string{}
+		for _, item := range v {
+			items = append(items, UNORDEREDPREFIX2+w.inlines(item))
+		}
+		return strings.Join(items, "\n")
+	case OrderedList:
+		items := []string{}
+		for i, item := range v {
+			items = append(items, fmt.Sprintf("%d. %s", i+1, w.inlines(item)))
+		}
+		return strings.Join(items, "\n")
+	case DefinitionList:
+		lines := []string{}
+		for _, entry := range v {
+			for _, term := range entry.Terms {
+				lines = append(lines, w.inlines(term))
+			}
+			for _, description := range entry.Descriptions {
+				lines = append(lines, w.description(description, width))
+			}
+		}
+		return strings.Join(lines, "\n")
+	case Admonition:
+		opening := ADMONITIONFENCE + v.Type
+		if len(v.Title) > 0 {
+			opening += " " + w.inlines(v.Title)
+		}
+		blocks := []string{opening}
+		for i, b := range v.Content {
+			content := w.block(b, width)
+			if i > 0 {
+				content = "\n" + content
+			}
+			blocks = append(blocks, content)
+		}
+		return strings.Join(append(blocks, ADMONITIONFENCE), "\n")
+	case Figure:
+		// the id of the figure goes back to its image, which figurify takes
+		// it from
+		image := v.Image
+		attributes := v.Attributes.without("id")
+		if id, isI := v.Attributes["id"]; isI {
+			image.Attributes = mergeAttributes(image.Attributes.Clone(), Attributes{"id": id})
+		}
+		return leadingAttributeLine(attributes) + w.inline(image)
+	case Table:
+		return leadingAttributeLine(v.Attributes) + w.table(v)
+	default:
+		return w.inline(node)
+	}
+}
+
+// description renders a definition, whose continuation lines are indented.
+// Blocks after the first are set apart by blank lines.
+func (w *markdownWriter) description(description DefinitionDescription, width int) string {
+	if len(description) == 0 || isInline(description[0]) {
+		return prefixLines(w.wrap(description, width-len(DEFINITIONINDENT1)), DEFINITIONPREFIX, DEFINITIONINDENT1)
+	}
+	blocks := []string{}
+	for i, b := range description {
+		prefix := DEFINITIONINDENT1
+		if i == 0 {
+			prefix = DEFINITIONPREFIX
+		}
+		blocks = append(blocks, prefixLines(w.block(b, width-len(DEFINITIONINDENT1)), prefix, DEFINITIONINDENT1))
+	}
+	return strings.Join(blocks, BLOCKDELIMITER)
+}
+
+func (w *markdownWriter) table(table Table) string {
+	rows := [][]string{}
+	header := []string{}
+	for _, cell := range table.Header {
+		header = append(header, w.tableCell(cell))
+	}
+	rows = append(rows, header)
+	for _, row := range table.Rows {
+		cells := []string{}
+		for _, cell := range row {
+			cells = append(cells, w.tableCell(cell))
+		}
+		rows = append(rows, cells)
+	}
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 3)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	lines := []string{}
+	for i, row := range rows {
+		cells := make([]string, len(widths))
+		for j := range widths {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+			cells[j] = cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			separators := []string{}
+			for _, width := range widths {
+				separators = append(separators, strings.Repeat("-", width))
+			}
+			lines = append(lines, "| "+strings.Join(separators, " | ")+" |")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tableCell renders a cell of a table, escaping the pipes that would end it
+func (w *markdownWriter) tableCell(cell TableItem) string {
+	return strings.ReplaceAll(w.inlines(cell), TABLEDELIMITER, "\\"+TABLEDELIMITER)
+}
+
+func (w *markdownWriter) inlines(nodes []Node) string {
+	builder := new(strings.Builder)
+	for _, n := range nodes {
+		builder.WriteString(w.inline(n))
+	}
+	return builder.String()
+}
+
+func (w *markdownWriter) inline(node Node) string {
+	switch v := node.(type) {
+	case Plain:
+		return string(v)
+	case Bold:
+		return BOLDDELIMITER1 + string(v) + BOLDDELIMITER1
+	case Italic:
+		return ITALICDELIMITER1 + string(v) + ITALICDELIMITER1
+	case Underline:
+		return UNDERLINEDELIMITER + string(v) + UNDERLINEDELIMITER
+	case InlineCode:
+		return INLINECODEDELIMITER + string(v) + INLINECODEDELIMITER
+	case Crossed:
+		return CROSSEDDELIMITER + string(v) + CROSSEDDELIMITER
+	case Highlight:
+		return HIGHLIGHTDELIMITER + string(v) + HIGHLIGHTDELIMITER
+	case Superscript:
+		return SUPERSCRIPTDELIMITER + string(v) + SUPERSCRIPTDELIMITER
+	case Subscript:
+		return SUBSCRIPTDELIMITER + string(v) + SUBSCRIPTDELIMITER
+	case Inserted:
+		return INSERTDELIMITER + string(v) + INSERTDELIMITER
+	case InlineMath:
+		d := w.options.InlineMathDelimiters[0]
+		return d.Open + string(v) + d.Close
+	case Hyperlink:
+		content := w.inlines(v.Content)
+		if name, isF := w.figures[strings.TrimPrefix(v.Link, "#")]; isF && content == name && strings.HasPrefix(v.Link, "#") {
+			content = ""
+		}
+		return "[" + content + "](" + v.Link + ")" + v.Attributes.MarkdownRender()
+	case Image:
+		destination := v.Path
+		switch {
+		case len(v.Title) == 0:
+		case !strings.Contains(v.Title, "\""):
+			destination += " \"" + v.Title + "\""
+		case !strings.Contains(v.Title, "'"):
+			destination += " '" + v.Title + "'"
+		default:
+			destination += " \"" + strings.ReplaceAll(v.Title, "\"", "\\\"") + "\""
+		}
+		return "![" + w.inlines(v.Content) + "](" + destination + ")" + v.Attributes.MarkdownRender()
+	case Abbreviation:
+		w.abbreviations[v.Text] = v.Title
+		return v.Text
+	case Emoji:
+		return ":" + v.Name + ":"
+	case CrossReference:
+		return CROSSREFERENCEPREFIX + v.Label
+	case WikiLink:
+		if len(v.Content) == 1 && v.Content[0] == Plain(v.Page) {
+			return "[[" + v.Page + "]]"
+		}
+		return "[[" + v.Page + "|" + w.inlines(v.Content) + "]]"
+	default:
+		// blocks within inline content, and custom nodes, have no
+		// Markdown of their own to go back to, so they are written as
+		// HTML, which is kept as it is by the parser and HTML rendering
+		return htmlString(node.ToHTML(), w.options)
+	}
+}
+
+// wrap renders inline nodes, filling lines up to width when positive. Only
+// the spaces of plain text count as breaking points, so every other node
+// stays on a single line. Hard line breaks end a line in any case.
+func (w *markdownWriter) wrap(nodes []Node, width int) string {
+	if w.width <= 0 {
+		return w.inlines(nodes)
+	}
+	// words holds the words to fill lines with, and "\n" for hard breaks
+	words := []string{}
+	word := new(strings.Builder)
+	endWord := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, n := range nodes {
+		text, isP := n.(Plain)
+		if !isP {
+			word.WriteString(w.inline(n))
+			continue
+		}
+		rest := string(text)
+		for len(rest) > 0 {
+			start := strings.IndexFunc(rest, unicode.IsSpace)
+			if start < 0 {
+				word.WriteString(rest)
+				break
+			}
+			word.WriteString(rest[:start])
+			endWord()
+			rest = rest[start:]
+			end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) })
+			if end < 0 {
+				end = len(rest)
+			}
+			if strings.Contains(rest[:end], HARDBREAK) {
+				words = append(words, "\n")
+			}
+			rest = rest[end:]
+		}
+	}
+	endWord()
+
+	lines := []string{}
+	line := ""
+	for _, wd := range words {
+		switch {
+		case wd == "\n":
+			if len(line) > 0 {
+				lines = append(lines, line+strings.TrimSuffix(HARDBREAK, "\n"))
+			}
+			line = ""
+		case len(line) == 0:
+			line = wd
+		// a first line holding only an attribute list would be read as the
+		// attributes of the block
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(wd) <= width || !startsLine(wd) || len(lines) == 0 && attributesPattern.MatchString(line):
+			line += " " + wd
+		default:
+			lines = append(lines, line)
+			line = wd
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// startsLine reports whether word can start a wrapped line, which it cannot
+// when the line would then be read as a definition, an abbreviation or a
+// fence
+func startsLine(word string) bool {
+	if word == strings.TrimSpace(DEFINITIONPREFIX) {
+		return false
+	}
+	for _, prefix := range []string{"*[", CODEDELIMITER, ADMONITIONFENCE} {
+		if strings.HasPrefix(word, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func leadingAttributeLine(attributes Attributes) string {
+	if line := attributes.MarkdownRender(); len(line) > 0 {
+		return line + "\n"
+	}
+	return ""
+}
+
+// prefixLines prefixes the first line of text with first, and the others
+// with rest
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if i == 0 {
+			lines[i] = first + l
+		} else {
+			lines[i] = rest + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isInline(node Node) bool {
+	switch node.(type) {
+	case Plain, Bold, Italic, Underline, InlineCode, Crossed, InlineMath, Highlight, Superscript,
+		Subscript, Inserted, Hyperlink, Image, Abbreviation, Emoji, CrossReference, WikiLink:
+		return true
+	default:
+		return false
+	}
+}
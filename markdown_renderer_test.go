@@ -0,0 +1,178 @@
+package markdownrenderer
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMarkdownRenderer(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		extensions Extension
+		input      string
+		expected   string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "header and paragraph",
+			input:    "# Title {.lead #top}\n\n\n\nSome __bold__ and _it_",
+			expected: "# Title {#top .lead}\n\nSome **bold** and *it*\n",
+		},
+		{
+			name:     "lists",
+			input:    "* a\n* **b**\n\n1. one\n1. two",
+			expected: "- a\n- **b**\n\n1. one\n2. two\n",
+		},
+		{
+			name:     "quote and code",
+			input:    ">  indented\n> quote\n\n```go\nx := 1\n```",
+			expected: ">  indented\n> quote\n\n```go\nx := 1\n```\n",
+		},
+		{
+			name:     "links and images",
+			input:    "[a *b*](x.com){rel=me} ![alt](a.png 'Say \"hi\"') [[Page]] [[Page|other]] :tada: `c` $x$",
+			expected: "[a *b*](x.com){rel=me} ![alt](a.png 'Say \"hi\"') [[Page]] [[Page|other]] :tada: `c` $x$\n",
+		},
+		{
+			name:     "github admonition",
+			input:    "> [!WARNING] Careful\n> body",
+			expected: ":::warning Careful\nbody\n:::\n",
+		},
+		{
+			name:     "definitions",
+			input:    "Term\n: first\n\tline\n: - a\n  - b",
+			expected: "Term\n: first\n  line\n: - a\n  - b\n",
+		},
+		{
+			name:     "abbreviations",
+			input:    "*[HTML]: Hyper Text Markup Language\nSome HTML",
+			expected: "Some HTML\n\n*[HTML]: Hyper Text Markup Language\n",
+		},
+		{
+			name:       "figure references",
+			extensions: EXTFIGURES,
+			input:      "See [](#a), [Plan](#a) and [](#b).\n\n![Plan](a.png){#a}",
+			expected:   "See [](#a), [](#a) and [](#b).\n\n![Plan](a.png){#a}\n",
+		},
+		{
+			name:     "wrapped",
+			width:    20,
+			input:    "{.intro}\nA paragraph with [a long link](https://example.com) that wraps\n\n> a quote that wraps around",
+			expected: "{.intro}\nA paragraph with\n[a long link](https://example.com)\nthat wraps\n\n> a quote that wraps\n> around\n",
+		},
+		{
+			name:     "wrapped hard break",
+			width:    20,
+			input:    "line one  \nline two and three",
+			expected: "line one  \nline two and three\n",
+		},
+		{
+			name:     "wrapped before definition",
+			width:    4,
+			input:    "a b : c",
+			expected: "a b :\nc\n",
+		},
+		{
+			name:     "title quotes",
+			input:    "![a](a.png \"it's \\\"x\\\"\")",
+			expected: "![a](a.png \"it's \\\"x\\\"\")\n",
+		},
+	}
+	defer func() {
+		Extensions = 0
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Extensions = tt.extensions
+			got := FormatMarkdown(tt.input, tt.width)
+			if got != tt.expected {
+				t.Errorf("FormatMarkdown(%q, %d)\n  got:      %q\n  expected: %q", tt.input, tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererTable(t *testing.T) {
+	table := Table{
+		Header: TableHeader{{Plain("Name")}, {Plain("Description")}},
+		Rows:   []TableRow{{{Bold("é")}, {Plain("a "), InlineCode("b")}}, {{Plain("long name")}}},
+	}
+	expected := "| Name      | Description |\n| --------- | ----------- |\n| **é**     | a `b`       |\n| long name |             |"
+	got := NewMarkdownRenderer(0).Render(NewElement(table))
+	if got != expected {
+		t.Errorf("Render(%v)\n  got:      %q\n  expected: %q", table, got, expected)
+	}
+}
+
+func TestMarkdownRendererRoundTrip(t *testing.T) {
+	inputs := []string{
+		"# **Bold** Title {#top .lead}\n\nSome *text* with `code`, ~~old~~ and $x^2$.\nSecond line.",
+		"{.note key=\"a b\"}\nA [link](x.com){rel=me title=\"it's \\\"x\\\"\"} and ![alt](a.png \"Title\") and [[Wiki Page|wiki]] :tada:",
+		"> quoted\n> twice\n\n---\n\n```\ncode\n\n```\n\n$$\nx\n$$ {#eq:x}",
+		"1. one\n2. **two**\n\n* a\n* b",
+		"Apple\nPomme\n: A fruit\n: A company\n  and more\nBanana\n: 1. yellow\n  2. long",
+		"Term\n: para one\n\n  para two\n\n  * a\n  * b\nNext\n: three",
+		":::note\nRead *this*\n\n:::tip Nested\ninner\n:::\n:::\n\n> [!warning] Careful\n> first",
+		"See @fig:a and [](#fig:a).\n\n{.wide}\n![First](a.png \"The *first*\"){#fig:a .big}",
+		"*[HTML]: Hyper Text Markup Language\n*[CSS]: Cascading Style Sheets\n\nSome HTML and CSS here",
+		"a literal \\*star\\* here, then\na line  \nbroken by hand and ``` a : fence ::: b *[c]",
+		"![a](a.png \"it's \\\"x\\\"\") ![b](b.png 'say \"hi\"')",
+		"{#x} hello world\n\n{.a b=c} {.d} text",
+		"See @tbl:a.\n\n{#tbl:a}\n| A | *B* |\n| --- | :-: |\n| 1 | a \\| b |\n| 2 |",
+	}
+	// wrapping only changes the whitespace of text
+	softBreaks := cmp.Transformer("softBreaks", func(text Plain) string {
+		return strings.Join(strings.FieldsFunc(strings.ReplaceAll(string(text), HARDBREAK, " \x00 "), unicode.IsSpace), " ")
+	})
+
+	defer func() {
+		Extensions = 0
+		FigureNumbering = false
+	}()
+	Extensions = EXTFIGURES | EXTCROSSREFERENCES
+	FigureNumbering = true
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			expected := Parse(input).Build()
+			markdown := RenderMarkdown(input, NewMarkdownRenderer(0))
+			got := Parse(markdown).Build()
+			if diff := cmp.Diff(got, expected, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Parse(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", markdown, got, expected, diff)
+			}
+
+			for _, width := range []int{6, 12, 30} {
+				wrapped := FormatMarkdown(input, width)
+				got := Parse(wrapped).Build()
+				if diff := cmp.Diff(got, expected, cmpopts.EquateEmpty(), softBreaks); diff != "" {
+					t.Errorf("Parse(%q)\n  got:      %v\n  expected: %v\n  Diff:     %s", wrapped, got, expected, diff)
+				}
+			}
+
+			wrapped := FormatMarkdown(input, 30)
+			if again := FormatMarkdown(wrapped, 30); again != wrapped {
+				t.Errorf("FormatMarkdown(%q, 30)\n  got:      %q\n  expected: %q", wrapped, again, wrapped)
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererCustomNodes(t *testing.T) {
+	document := Document{Paragraph{Content: []Node{Plain("Fixed in "), jiraIssue("ABC-1")}}}
+	expected := "Fixed in <a href='https://jira.example.com/browse/ABC-1'>ABC-1</a>\n"
+	got := NewMarkdownRenderer(0).Render(NewElement(document))
+	if got != expected {
+		t.Errorf("Render(%v)\n  got:      %q\n  expected: %q", document, got, expected)
+	}
+	if html := RenderMarkdown(got, NewHTMLRenderer()); html != document.ToHTML().HTMLRender() {
+		t.Errorf("RenderMarkdown(%q) = %q, expected %q", got, html, document.ToHTML().HTMLRender())
+	}
+}
@@ -0,0 +1,496 @@
+package markdownrenderer
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const MATHMLNAMESPACE = "http://www.w3.org/1998/Math/MathML"
+
+var texGreek = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"omicron": "ο", "pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ",
+	"sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+}
+
+var texGreekUpper = map[string]string{
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ",
+	"Omega": "Ω",
+}
+
+var texOperators = map[string]string{
+	"pm": "±", "mp": "∓", "times": "×", "div": "÷", "cdot": "⋅", "ast": "∗",
+	"star": "⋆", "circ": "∘", "bullet": "∙", "le": "≤", "leq": "≤",
+	"ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝",
+	"ll": "≪", "gg": "≫", "in": "∈", "notin": "∉", "ni": "∋",
+	"subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇",
+	"cup": "∪", "cap": "∩", "setminus": "∖", "land": "∧", "wedge": "∧",
+	"lor": "∨", "vee": "∨", "neg": "¬", "lnot": "¬", "forall": "∀",
+	"exists": "∃", "to": "→", "rightarrow": "→", "leftarrow": "←",
+	"gets": "←", "leftrightarrow": "↔", "Rightarrow": "⇒",
+	"Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺",
+	"mapsto": "↦", "sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫",
+	"iint": "∬", "iiint": "∭", "oint": "∮", "bigcup": "⋃", "bigcap": "⋂",
+	"partial": "∂", "nabla": "∇", "infty": "∞", "emptyset": "∅",
+	"varnothing": "∅", "ldots": "…", "dots": "…", "cdots": "⋯",
+	"vdots": "⋮", "ddots": "⋱", "prime": "′", "mid": "∣", "parallel": "∥",
+	"perp": "⊥", "angle": "∠", "langle": "⟨", "rangle": "⟩",
+	"lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"vert": "|", "Vert": "‖", "{": "{", "}": "}", "|": "‖",
+	"hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+}
+
+var texFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true,
+	"csc": true, "arcsin": true, "arccos": true, "arctan": true,
+	"sinh": true, "cosh": true, "tanh": true, "log": true, "ln": true,
+	"lg": true, "exp": true, "lim": true, "max": true, "min": true,
+	"sup": true, "inf": true, "det": true, "dim": true, "ker": true,
+	"gcd": true, "arg": true, "deg": true, "Pr": true,
+}
+
+var texSpaces = map[string]string{
+	",": "0.167em", ":": "0.222em", ";": "0.278em", "!": "-0.167em",
+	" ": "0.333em", "quad": "1em", "qquad": "2em",
+}
+
+var texFonts = map[string]string{
+	"mathbf": "bold", "boldsymbol": "bold-italic", "mathit": "italic",
+	"mathbb": "double-struck", "mathcal": "script", "mathfrak": "fraktur",
+	"mathsf": "sans-serif", "mathtt": "monospace",
+}
+
+var texAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "overline": "¯", "vec": "→",
+	"dot": "˙", "ddot": "¨", "tilde": "~", "widetilde": "~",
+}
+
+// texMatrices maps matrix environments to their opening and closing fences
+var texMatrices = map[string][2]string{
+	"matrix":  {"", ""},
+	"pmatrix": {"(", ")"},
+	"bmatrix": {"[", "]"},
+	"Bmatrix": {"{", "}"},
+	"vmatrix": {"|", "|"},
+	"Vmatrix": {"‖", "‖"},
+	"cases":   {"{", ""},
+}
+
+// TeXToMathML converts the TeX source of a formula to a MathML element. It
+// covers the commonly used subset of LaTeX math and returns an error on any
+// command it does not support, so callers can fall back to the source.
+func TeXToMathML(tex string, display bool) (string, error) {
+	p := &texParser{src: tex}
+	body, err := p.parseRow()
+	if err != nil {
+		return "", err
+	}
+	if !p.eof() {
+		return "", fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:p.pos+1], p.pos)
+	}
+	mode := "inline"
+	if display {
+		mode = "block"
+	}
+
+	return fmt.Sprintf("<math xmlns='%s' display='%s'>%s</math>", MATHMLNAMESPACE, mode, body), nil
+}
+
+type texParser struct {
+	src string
+	pos int
+}
+
+func (p *texParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *texParser) skipSpaces() {
+	for !p.eof() && strings.ContainsRune(" \t\n\r", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// at reports whether the input continues with token, making sure a command
+// such as \right is not mistaken for the start of \rightarrow
+func (p *texParser) at(token string) bool {
+	if !strings.HasPrefix(p.src[p.pos:], token) {
+		return false
+	}
+	next := p.pos + len(token)
+	if strings.HasPrefix(token, "\\") && isLetter(token[len(token)-1]) && next < len(p.src) {
+		return !isLetter(p.src[next])
+	}
+	return true
+}
+
+func (p *texParser) expect(token string) error {
+	p.skipSpaces()
+	if !p.at(token) {
+		return fmt.Errorf("expected %q at offset %d", token, p.pos)
+	}
+	p.pos += len(token)
+	return nil
+}
+
+// parseRow parses atoms with their scripts until the end of the input or
+// one of the terminators, which is left unconsumed
+func (p *texParser) parseRow(terminators ...string) (string, error) {
+	atoms := []string{}
+	for {
+		p.skipSpaces()
+		if p.eof() || p.atAny(terminators) || p.at("}") {
+			break
+		}
+		atom, err := p.parseAtom(false)
+		if err != nil {
+			return "", err
+		}
+		atom, err = p.parseScripts(atom)
+		if err != nil {
+			return "", err
+		}
+		atoms = append(atoms, atom)
+	}
+
+	return mathRow(atoms), nil
+}
+
+func (p *texParser) atAny(tokens []string) bool {
+	for _, t := range tokens {
+		if p.at(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *texParser) parseScripts(base string) (string, error) {
+	var sub, sup string
+	for {
+		p.skipSpaces()
+		var script *string
+		if p.at("_") {
+			script = &sub
+		} else if p.at("^") {
+			script = &sup
+		} else if p.at("'") {
+			p.pos++
+			sup += "<mo>′</mo>"
+			continue
+		} else {
+			break
+		}
+		if len(*script) > 0 {
+			return "", fmt.Errorf("double script at offset %d", p.pos)
+		}
+		p.pos++
+		arg, err := p.parseArgument()
+		if err != nil {
+			return "", err
+		}
+		*script = arg
+	}
+
+	switch {
+	case len(sub) > 0 && len(sup) > 0:
+		return fmt.Sprintf("<msubsup>%s%s%s</msubsup>", base, sub, sup), nil
+	case len(sub) > 0:
+		return fmt.Sprintf("<msub>%s%s</msub>", base, sub), nil
+	case len(sup) > 0:
+		return fmt.Sprintf("<msup>%s%s</msup>", base, sup), nil
+	}
+	return base, nil
+}
+
+// parseArgument parses a braced group or a single token, as TeX does for
+// the arguments of commands and scripts
+func (p *texParser) parseArgument() (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", fmt.Errorf("missing argument at end of input")
+	}
+	return p.parseAtom(true)
+}
+
+func (p *texParser) parseAtom(single bool) (string, error) {
+	c := p.src[p.pos]
+	switch {
+	case c == '{':
+		p.pos++
+		row, err := p.parseRow()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("}"); err != nil {
+			return "", err
+		}
+		return row, nil
+	case c == '}':
+		return "", fmt.Errorf("unexpected \"}\" at offset %d", p.pos)
+	case c == '^' || c == '_':
+		// scripts without a base attach to an empty row
+		return "<mrow></mrow>", nil
+	case c == '\\':
+		return p.parseCommand()
+	case isDigit(c):
+		start := p.pos
+		p.pos++
+		for !single && !p.eof() && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		return fmt.Sprintf("<mn>%s</mn>", p.src[start:p.pos]), nil
+	case isLetter(c):
+		p.pos++
+		return fmt.Sprintf("<mi>%c</mi>", c), nil
+	case c == '-':
+		p.pos++
+		return "<mo>−</mo>", nil
+	case c < utf8.RuneSelf:
+		p.pos++
+		return fmt.Sprintf("<mo>%s</mo>", html.EscapeString(string(c))), nil
+	default:
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+		return fmt.Sprintf("<mi>%c</mi>", r), nil
+	}
+}
+
+func (p *texParser) parseCommand() (string, error) {
+	name := p.readCommandName()
+
+	if width, isS := texSpaces[name]; isS {
+		return fmt.Sprintf("<mspace width='%s'/>", width), nil
+	}
+	if letter, isG := texGreek[name]; isG {
+		return fmt.Sprintf("<mi>%s</mi>", letter), nil
+	}
+	if letter, isG := texGreekUpper[name]; isG {
+		return fmt.Sprintf("<mi mathvariant='normal'>%s</mi>", letter), nil
+	}
+	if operator, isO := texOperators[name]; isO {
+		return fmt.Sprintf("<mo>%s</mo>", operator), nil
+	}
+	if texFunctions[name] {
+		return fmt.Sprintf("<mi>%s</mi>", name), nil
+	}
+	if variant, isF := texFonts[name]; isF {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<mstyle mathvariant='%s'>%s</mstyle>", variant, arg), nil
+	}
+	if accent, isA := texAccents[name]; isA {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<mover accent='true'>%s<mo>%s</mo></mover>", arg, accent), nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		return p.parseFraction("<mfrac>%s%s</mfrac>")
+	case "binom":
+		return p.parseFraction("<mrow><mo>(</mo><mfrac linethickness='0'>%s%s</mfrac><mo>)</mo></mrow>")
+	case "sqrt":
+		return p.parseRoot()
+	case "text", "textrm", "mbox":
+		text, err := p.readBraced()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<mtext>%s</mtext>", html.EscapeString(text)), nil
+	case "mathrm", "operatorname":
+		text, err := p.readBraced()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<mi mathvariant='normal'>%s</mi>", html.EscapeString(text)), nil
+	case "left":
+		return p.parseFenced()
+	case "begin":
+		return p.parseEnvironment()
+	}
+
+	return "", fmt.Errorf("unsupported command \\%s", name)
+}
+
+func (p *texParser) readCommandName() string {
+	p.pos++
+	if p.eof() {
+		return ""
+	}
+	start := p.pos
+	if !isLetter(p.src[p.pos]) {
+		p.pos++
+		return p.src[start:p.pos]
+	}
+	for !p.eof() && isLetter(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// readBraced returns the raw content of a braced group, for commands whose
+// argument is text rather than math
+func (p *texParser) readBraced() (string, error) {
+	if err := p.expect("{"); err != nil {
+		return "", err
+	}
+	end := strings.Index(p.src[p.pos:], "}")
+	if end < 0 {
+		return "", fmt.Errorf("unclosed group at offset %d", p.pos)
+	}
+	text := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return text, nil
+}
+
+func (p *texParser) parseFraction(format string) (string, error) {
+	numerator, err := p.parseArgument()
+	if err != nil {
+		return "", err
+	}
+	denominator, err := p.parseArgument()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(format, numerator, denominator), nil
+}
+
+func (p *texParser) parseRoot() (string, error) {
+	p.skipSpaces()
+	if !p.at("[") {
+		radicand, err := p.parseArgument()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<msqrt>%s</msqrt>", radicand), nil
+	}
+	p.pos++
+	index, err := p.parseRow("]")
+	if err != nil {
+		return "", err
+	}
+	if err := p.expect("]"); err != nil {
+		return "", err
+	}
+	radicand, err := p.parseArgument()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<mroot>%s%s</mroot>", radicand, index), nil
+}
+
+func (p *texParser) parseFenced() (string, error) {
+	opening, err := p.readDelimiter()
+	if err != nil {
+		return "", err
+	}
+	inner, err := p.parseRow("\\right")
+	if err != nil {
+		return "", err
+	}
+	if err := p.expect("\\right"); err != nil {
+		return "", err
+	}
+	closing, err := p.readDelimiter()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<mrow>%s%s%s</mrow>", fence(opening), inner, fence(closing)), nil
+}
+
+func (p *texParser) readDelimiter() (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", fmt.Errorf("missing delimiter at end of input")
+	}
+	if p.src[p.pos] != '\\' {
+		p.pos++
+		return html.EscapeString(p.src[p.pos-1 : p.pos]), nil
+	}
+	name := p.readCommandName()
+	if operator, isO := texOperators[name]; isO {
+		return operator, nil
+	}
+	return "", fmt.Errorf("unsupported delimiter \\%s", name)
+}
+
+func (p *texParser) parseEnvironment() (string, error) {
+	name, err := p.readBraced()
+	if err != nil {
+		return "", err
+	}
+	fences, isM := texMatrices[name]
+	if !isM {
+		return "", fmt.Errorf("unsupported environment %s", name)
+	}
+
+	table := new(strings.Builder)
+	if name == "cases" {
+		table.WriteString("<mtable columnalign='left'><mtr>")
+	} else {
+		table.WriteString("<mtable><mtr>")
+	}
+	for {
+		cell, err := p.parseRow("&", "\\\\", "\\end")
+		if err != nil {
+			return "", err
+		}
+		table.WriteString(fmt.Sprintf("<mtd>%s</mtd>", cell))
+		p.skipSpaces()
+		if p.at("&") {
+			p.pos++
+		} else if p.at("\\\\") {
+			p.pos += 2
+			table.WriteString("</mtr><mtr>")
+		} else if p.at("\\end") {
+			p.pos += len("\\end")
+			break
+		} else {
+			return "", fmt.Errorf("unclosed environment %s", name)
+		}
+	}
+	table.WriteString("</mtr></mtable>")
+	end, err := p.readBraced()
+	if err != nil {
+		return "", err
+	}
+	if end != name {
+		return "", fmt.Errorf("environment %s closed by %s", name, end)
+	}
+
+	return fmt.Sprintf("<mrow>%s%s%s</mrow>", fence(fences[0]), table, fence(fences[1])), nil
+}
+
+func mathRow(atoms []string) string {
+	if len(atoms) == 1 {
+		return atoms[0]
+	}
+	return fmt.Sprintf("<mrow>%s</mrow>", strings.Join(atoms, ""))
+}
+
+func fence(delimiter string) string {
+	if len(delimiter) == 0 || delimiter == "." {
+		return ""
+	}
+	return fmt.Sprintf("<mo fence='true'>%s</mo>", delimiter)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
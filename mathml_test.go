@@ -0,0 +1,118 @@
+package markdownrenderer
+
+import "testing"
+
+func TestTeXToMathML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "identifiers and numbers", input: "x + 12.5", expected: "<mrow><mi>x</mi><mo>+</mo><mn>12.5</mn></mrow>"},
+		{name: "minus sign", input: "a - b", expected: "<mrow><mi>a</mi><mo>−</mo><mi>b</mi></mrow>"},
+		{name: "superscript", input: "x^2", expected: "<msup><mi>x</mi><mn>2</mn></msup>"},
+		{name: "superscript takes one digit", input: "x^23", expected: "<mrow><msup><mi>x</mi><mn>2</mn></msup><mn>3</mn></mrow>"},
+		{name: "subscript group", input: "a_{ij}", expected: "<msub><mi>a</mi><mrow><mi>i</mi><mi>j</mi></mrow></msub>"},
+		{name: "sub and superscript", input: "x_1^2", expected: "<msubsup><mi>x</mi><mn>1</mn><mn>2</mn></msubsup>"},
+		{name: "prime", input: "f'", expected: "<msup><mi>f</mi><mo>′</mo></msup>"},
+		{name: "fraction", input: "\\frac{a}{b+1}", expected: "<mfrac><mi>a</mi><mrow><mi>b</mi><mo>+</mo><mn>1</mn></mrow></mfrac>"},
+		{name: "square root", input: "\\sqrt{x}", expected: "<msqrt><mi>x</mi></msqrt>"},
+		{name: "nth root", input: "\\sqrt[3]{x}", expected: "<mroot><mi>x</mi><mn>3</mn></mroot>"},
+		{name: "greek", input: "\\alpha\\Omega", expected: "<mrow><mi>α</mi><mi mathvariant='normal'>Ω</mi></mrow>"},
+		{name: "operators", input: "a \\leq b \\times c", expected: "<mrow><mi>a</mi><mo>≤</mo><mi>b</mi><mo>×</mo><mi>c</mi></mrow>"},
+		{name: "sum with limits", input: "\\sum_{i=1}^n", expected: "<msubsup><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup>"},
+		{name: "function", input: "\\sin x", expected: "<mrow><mi>sin</mi><mi>x</mi></mrow>"},
+		{name: "text", input: "\\text{if } x", expected: "<mrow><mtext>if </mtext><mi>x</mi></mrow>"},
+		{name: "escaped operator", input: "a<b", expected: "<mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow>"},
+		{name: "left right", input: "\\left( x \\right)", expected: "<mrow><mo fence='true'>(</mo><mi>x</mi><mo fence='true'>)</mo></mrow>"},
+		{name: "rightarrow is not right", input: "a \\rightarrow b", expected: "<mrow><mi>a</mi><mo>→</mo><mi>b</mi></mrow>"},
+		{name: "space", input: "a\\,b", expected: "<mrow><mi>a</mi><mspace width='0.167em'/><mi>b</mi></mrow>"},
+		{name: "accent", input: "\\vec{v}", expected: "<mover accent='true'><mi>v</mi><mo>→</mo></mover>"},
+		{name: "font", input: "\\mathbb{R}", expected: "<mstyle mathvariant='double-struck'><mi>R</mi></mstyle>"},
+		{
+			name:     "matrix",
+			input:    "\\begin{pmatrix} a & b \\\\ c & d \\end{pmatrix}",
+			expected: "<mrow><mo fence='true'>(</mo><mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable><mo fence='true'>)</mo></mrow>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TeXToMathML(tt.input, false)
+			if err != nil {
+				t.Fatalf("TeXToMathML(%q) returned error: %v", tt.input, err)
+			}
+			expected := "<math xmlns='" + MATHMLNAMESPACE + "' display='inline'>" + tt.expected + "</math>"
+			if got != expected {
+				t.Errorf("TeXToMathML(%q)\n  got:      %q\n  expected: %q", tt.input, got, expected)
+			}
+		})
+	}
+}
+
+func TestTeXToMathMLDisplay(t *testing.T) {
+	got, err := TeXToMathML("x", true)
+	if err != nil {
+		t.Fatalf("TeXToMathML returned error: %v", err)
+	}
+	expected := "<math xmlns='" + MATHMLNAMESPACE + "' display='block'><mi>x</mi></math>"
+	if got != expected {
+		t.Errorf("TeXToMathML(%q, true) = %q, expected %q", "x", got, expected)
+	}
+}
+
+func TestTeXToMathMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "unsupported command", input: "\\foo{x}"},
+		{name: "unsupported environment", input: "\\begin{align} x \\end{align}"},
+		{name: "unclosed group", input: "\\frac{a}{b"},
+		{name: "stray closing brace", input: "a}"},
+		{name: "double superscript", input: "x^2^3"},
+		{name: "missing argument", input: "x^"},
+		{name: "mismatched environment", input: "\\begin{matrix} a \\end{pmatrix}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := TeXToMathML(tt.input, false); err == nil {
+				t.Errorf("TeXToMathML(%q) = %q, expected an error", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestMathConverter(t *testing.T) {
+	defer func() { MathConverter = nil }()
+	MathConverter = TeXToMathML
+
+	tests := []struct {
+		name     string
+		input    HTMLNode
+		expected string
+	}{
+		{
+			name:     "converted inline",
+			input:    HTMLInlineMath{Text: "x^2"},
+			expected: "<span class='math inline'><math xmlns='" + MATHMLNAMESPACE + "' display='inline'><msup><mi>x</mi><mn>2</mn></msup></math></span>",
+		},
+		{
+			name:     "converted display",
+			input:    HTMLDisplayMath{Text: "\\pi"},
+			expected: "<div class='math display'><math xmlns='" + MATHMLNAMESPACE + "' display='block'><mi>π</mi></math></div>",
+		},
+		{
+			name:     "unsupported falls back to source",
+			input:    HTMLInlineMath{Text: "\\foo < 1"},
+			expected: "<span class='math inline'>\\(\\foo &lt; 1\\)</span>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.HTMLRender()
+			if got != tt.expected {
+				t.Errorf("%T.HTMLRender() = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package markdownrenderer
+
+// Options hold the configuration of a Converter. Fields but the last two
+// stand for the package-level variables of the same name.
+type Options struct {
+	Extensions            Extension
+	BlockRules            []BlockRule
+	InlineRules           []InlineRule
+	Strikethrough         bool
+	AdmonitionKinds       []string
+	InlineMathDelimiters  []MathDelimiter
+	DisplayMathDelimiters []MathDelimiter
+	LegacyTags            bool
+	MathConverter         func(tex string, display bool) (string, error)
+	WikiResolver          WikiLinkResolver
+	ReferenceURL          func(ref Reference) string
+	EmojiImageURL         string
+	TypographerLocale     string
+	FigureNumbering       bool
+	FigureLabel           string
+	SectionLabel          string
+	TableLabel            string
+	EquationLabel         string
+	// Transformers run in order on parsed documents
+	Transformers []Transformer
+	// Renderer renders converted documents, NewHTMLRenderer() when nil
+	Renderer Renderer
+}
+
+// Option sets some of the options of a Converter. Presets are options too,
+// which reset every option, so they come before the options changing them.
+type Option func(options *Options)
+
+// legacyOptions are the defaults of the package-level variables, but for the
+// rules, which depend on the parsers
+var legacyOptions = Options{
+	Extensions:            Extensions,
+	Strikethrough:         Strikethrough,
+	AdmonitionKinds:       AdmonitionKinds,
+	InlineMathDelimiters:  InlineMathDelimiters,
+	DisplayMathDelimiters: DisplayMathDelimiters,
+	LegacyTags:            LegacyTags,
+	MathConverter:         MathConverter,
+	WikiResolver:          WikiResolver,
+	ReferenceURL:          ReferenceURL,
+	EmojiImageURL:         EmojiImageURL,
+	TypographerLocale:     TypographerLocale,
+	FigureNumbering:       FigureNumbering,
+	FigureLabel:           FigureLabel,
+	SectionLabel:          SectionLabel,
+	TableLabel:            TableLabel,
+	EquationLabel:         EquationLabel,
+}
+
+// Legacy is the dialect of the package-level functions with their default
+// configuration, and the one of converters built without presets
+func Legacy(options *Options) {
+	*options = legacyOptions
+	options.AdmonitionKinds = append([]string{}, legacyOptions.AdmonitionKinds...)
+	options.InlineMathDelimiters = append([]MathDelimiter{}, legacyOptions.InlineMathDelimiters...)
+	options.DisplayMathDelimiters = append([]MathDelimiter{}, legacyOptions.DisplayMathDelimiters...)
+	options.BlockRules = defaultBlockRules()
+	options.InlineRules = defaultInlineRules()
+}
+
+// CommonMark restricts the syntax to the constructs of CommonMark: headers,
+// breaks, fenced code, quotes, lists, links, images, emphasis and code spans.
+// They are parsed as in the other dialects rather than as the CommonMark
+// specification says: blocks only end at blank lines, quotes may also be
+// indented by two spaces or a tab, ordered list items are all numbered "1."
+// or counted from "1.", and emphasis delimiters pair from left to right,
+// within words too.
+func CommonMark(options *Options) {
+	Legacy(options)
+	options.BlockRules = blockRulesNamed("header", "break", "code", "quote", "ordered_list", "unordered_list")
+	options.InlineRules = inlineRulesNamed("image", "hyperlink", "simple")
+	options.Strikethrough = false
+}
+
+// GFM extends CommonMark with the syntax of GitHub Flavored Markdown:
+// tables, strikethrough, alerts, math, emoji shortcodes and references to
+// users, issues and commits, which are linked when ReferenceURL is set
+func GFM(options *Options) {
+	CommonMark(options)
+	options.BlockRules = blockRulesNamed("header", "break", "code", "display_math", "admonition", "quote", "ordered_list", "unordered_list", "table")
+	options.InlineRules = inlineRulesNamed("math", "image", "hyperlink", "emoji", "simple", "reference")
+	options.Strikethrough = true
+}
+
+func blockRulesNamed(names ...string) []BlockRule {
+	rules := []BlockRule{}
+	for _, rule := range defaultBlockRules() {
+		for _, name := range names {
+			if rule.Name == name {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+func inlineRulesNamed(names ...string) []InlineRule {
+	rules := []InlineRule{}
+	for _, rule := range defaultInlineRules() {
+		for _, name := range names {
+			if rule.Name == name {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+// WithExtensions enables extensions, in addition to those already enabled
+func WithExtensions(extensions Extension) Option {
+	return func(options *Options) {
+		options.Extensions |= extensions
+	}
+}
+
+// WithBlockRules adds block rules to the syntax
+func WithBlockRules(rules ...BlockRule) Option {
+	return func(options *Options) {
+		options.BlockRules = append(options.BlockRules, rules...)
+	}
+}
+
+// WithInlineRules adds inline rules to the syntax
+func WithInlineRules(rules ...InlineRule) Option {
+	return func(options *Options) {
+		options.InlineRules = append(options.InlineRules, rules...)
+	}
+}
+
+func WithStrikethrough(strikethrough bool) Option {
+	return func(options *Options) {
+		options.Strikethrough = strikethrough
+	}
+}
+
+func WithLegacyTags(legacyTags bool) Option {
+	return func(options *Options) {
+		options.LegacyTags = legacyTags
+	}
+}
+
+func WithMathConverter(converter func(tex string, display bool) (string, error)) Option {
+	return func(options *Options) {
+		options.MathConverter = converter
+	}
+}
+
+func WithWikiResolver(resolver WikiLinkResolver) Option {
+	return func(options *Options) {
+		options.WikiResolver = resolver
+	}
+}
+
+func WithReferenceURL(referenceURL func(ref Reference) string) Option {
+	return func(options *Options) {
+		options.ReferenceURL = referenceURL
+	}
+}
+
+func WithEmojiImageURL(pattern string) Option {
+	return func(options *Options) {
+		options.EmojiImageURL = pattern
+	}
+}
+
+func WithTypographerLocale(locale string) Option {
+	return func(options *Options) {
+		options.TypographerLocale = locale
+	}
+}
+
+func WithFigureNumbering(numbering bool) Option {
+	return func(options *Options) {
+		options.FigureNumbering = numbering
+	}
+}
+
+// WithTransformers adds transformers to run on parsed documents, after
+// those already added
+func WithTransformers(transformers ...Transformer) Option {
+	return func(options *Options) {
+		options.Transformers = append(options.Transformers, transformers...)
+	}
+}
+
+func WithRenderer(renderer Renderer) Option {
+	return func(options *Options) {
+		options.Renderer = renderer
+	}
+}
+
+// currentOptions returns the package-level variables, which the
+// package-level functions parse and render with
+func currentOptions() Options {
+	return Options{
+		Extensions:            Extensions,
+		BlockRules:            BlockRules,
+		InlineRules:           InlineRules,
+		Strikethrough:         Strikethrough,
+		AdmonitionKinds:       AdmonitionKinds,
+		InlineMathDelimiters:  InlineMathDelimiters,
+		DisplayMathDelimiters: DisplayMathDelimiters,
+		LegacyTags:            LegacyTags,
+		MathConverter:         MathConverter,
+		WikiResolver:          WikiResolver,
+		ReferenceURL:          ReferenceURL,
+		EmojiImageURL:         EmojiImageURL,
+		TypographerLocale:     TypographerLocale,
+		FigureNumbering:       FigureNumbering,
+		FigureLabel:           FigureLabel,
+		SectionLabel:          SectionLabel,
+		TableLabel:            TableLabel,
+		EquationLabel:         EquationLabel,
+	}
+}
+
+// hasInlineRule reports whether the options parse with the inline rule name
+func (o *Options) hasInlineRule(name string) bool {
+	for _, rule := range o.InlineRules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package markdownrenderer
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConverterPresets(t *testing.T) {
+	input := "# Title\n\nSome ~~old~~ *text* [[Page]] :tada: $x$ @octocat\n\n:::note\nbody\n:::\n\nTerm\n: Definition\n\n| A | B |\n| --- | --- |\n| 1 | 2 |"
+	tests := []struct {
+		name     string
+		options  []Option
+		expected string
+	}{
+		{
+			name:     "legacy",
+			expected: "<div><h1>Title</h1><p>Some <del>old</del> <em>text</em> <a href='Page'>Page</a> 🎉 <span class='math inline'>\\(x\\)</span> @octocat</p><div class='admonition note'><p class='admonition-title'>Note</p><p>body</p></div><dl><dt>Term</dt><dd>Definition</dd></dl><table><thead><tr><th>A</th><th>B</th></tr></thead><tbody><tr><td>1</td><td>2</td></tr></tbody></table></div>",
+		},
+		{
+			name:     "commonmark",
+			options:  []Option{CommonMark},
+			expected: "<div><h1>Title</h1><p>Some ~~old~~ <em>text</em> [[Page]] :tada: $x$ @octocat</p><p>:::note\nbody\n:::</p><p>Term\n: Definition</p><p>| A | B |\n| --- | --- |\n| 1 | 2 |</p></div>",
+		},
+		{
+			name: "gfm",
+			options: []Option{GFM, WithReferenceURL(func(ref Reference) string {
+				return "https://github.com/" + ref.Value
+			})},
+			expected: "<div><h1>Title</h1><p>Some <del>old</del> <em>text</em> [[Page]] 🎉 <span class='math inline'>\\(x\\)</span> <a href='https://github.com/octocat'>@octocat</a></p><div class='admonition note'><p class='admonition-title'>Note</p><p>body</p></div><p>Term\n: Definition</p><table><thead><tr><th>A</th><th>B</th></tr></thead><tbody><tr><td>1</td><td>2</td></tr></tbody></table></div>",
+		},
+		{
+			name:     "options after preset",
+			options:  []Option{CommonMark, WithStrikethrough(true), WithLegacyTags(true), WithBlockRules(blockRulesNamed("definition_list")...)},
+			expected: "<div><h1>Title</h1><p>Some <strike>old</strike> <i>text</i> [[Page]] :tada: $x$ @octocat</p><p>:::note\nbody\n:::</p><dl><dt>Term</dt><dd>Definition</dd></dl><p>| A | B |\n| --- | --- |\n| 1 | 2 |</p></div>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := NewConverter(tt.options...).Convert(input)
+			if got != tt.expected {
+				t.Errorf("Convert(%q)\n  got:      %q\n  expected: %q", input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConverterRestoresConfiguration(t *testing.T) {
+	input := "==mark== ~~old~~"
+	converter := NewConverter(CommonMark, WithExtensions(EXTHIGHLIGHT))
+	expected := "<div><p><mark>mark</mark> ~~old~~</p></div>"
+	if got, _ := converter.Convert(input); got != expected {
+		t.Errorf("Convert(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+	}
+	expected = "<div><p>==mark== <del>old</del></p></div>"
+	if got := MarkdownToHTML(input).HTMLRender(); got != expected {
+		t.Errorf("MarkdownToHTML(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+	}
+}
+
+func TestConverterIgnoresPackageVariables(t *testing.T) {
+	defer func() {
+		LegacyTags = false
+		Strikethrough = true
+		FigureLabel = "Figure"
+	}()
+	LegacyTags = true
+	Strikethrough = false
+	FigureLabel = "Fig."
+
+	converter := NewConverter(WithExtensions(EXTFIGURES), WithFigureNumbering(true))
+	input := "**bold** ~~old~~\n\n![Plan](a.png)"
+	expected := "<div><p><strong>bold</strong> <del>old</del></p><figure><img src='a.png'>Plan</img><figcaption>Figure 1: Plan</figcaption></figure></div>"
+	if got, _ := converter.Convert(input); got != expected {
+		t.Errorf("Convert(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+	}
+}
+
+func TestConverterReentrant(t *testing.T) {
+	inner := NewConverter(WithLegacyTags(true))
+	// a transformer converting with another converter while the outer one
+	// is converting
+	outer := NewConverter(WithTransformers(TransformerFunc(func(document *Element) {
+		for _, child := range document.Children {
+			html, _ := inner.Convert("*inner*")
+			child.Replace(Paragraph{Content: []Node{Plain(html)}})
+		}
+	})))
+
+	expected := "<div><p><div><p><i>inner</i></p></div></p></div>"
+	if got, _ := outer.Convert("outer"); got != expected {
+		t.Errorf("Convert(%q)\n  got:      %q\n  expected: %q", "outer", got, expected)
+	}
+}
+
+func TestConvertersConcurrently(t *testing.T) {
+	converters := map[string]*Converter{
+		"<div><p><strong>a</strong> ~~b~~</p></div>":    NewConverter(CommonMark),
+		"<div><p><b>a</b> <strike>b</strike></p></div>": NewConverter(WithLegacyTags(true)),
+	}
+	var group sync.WaitGroup
+	for expected, converter := range converters {
+		for range 10 {
+			group.Add(1)
+			go func() {
+				defer group.Done()
+				if got, _ := converter.Convert("**a** ~~b~~"); got != expected {
+					t.Errorf("Convert()\n  got:      %q\n  expected: %q", got, expected)
+				}
+			}()
+		}
+	}
+	group.Wait()
+}
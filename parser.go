@@ -0,0 +1,85 @@
+package markdownrenderer
+
+import "sort"
+
+// Parser parses Markdown in the dialect of its options. It reads nothing but
+// its options, so parsers are safe for concurrent use, whatever the
+// package-level variables hold. Rules are given the parser running them, to
+// parse nested content in the same dialect.
+type Parser struct {
+	options Options
+	// the rules of options, sorted by decreasing priority
+	blockRules  []BlockRule
+	inlineRules []InlineRule
+	// the abbreviations of the document being parsed, and their words
+	// longest first
+	abbreviations     map[string]string
+	abbreviationWords []string
+}
+
+// NewParser returns a parser of the Legacy dialect changed by options
+func NewParser(options ...Option) *Parser {
+	o := Options{}
+	Legacy(&o)
+	for _, option := range options {
+		option(&o)
+	}
+
+	return newParser(o)
+}
+
+func newParser(options Options) *Parser {
+	p := &Parser{options: options}
+	p.blockRules = append([]BlockRule{}, options.BlockRules...)
+	sort.SliceStable(p.blockRules, func(i, j int) bool {
+		return p.blockRules[i].Priority > p.blockRules[j].Priority
+	})
+	p.inlineRules = append([]InlineRule{}, options.InlineRules...)
+	sort.SliceStable(p.inlineRules, func(i, j int) bool {
+		return p.inlineRules[i].Priority > p.inlineRules[j].Priority
+	})
+
+	return p
+}
+
+// currentParser parses with the package-level variables, as the
+// package-level functions do
+func currentParser() *Parser {
+	return newParser(currentOptions())
+}
+
+// Options returns the options of the parser
+func (p *Parser) Options() Options {
+	return p.options
+}
+
+func (p *Parser) enabled(extension Extension) bool {
+	return p.options.Extensions&extension != 0
+}
+
+func (p *Parser) hasBlockRule(name string) bool {
+	for _, rule := range p.blockRules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) hasInlineRule(name string) bool {
+	for _, rule := range p.inlineRules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withAbbreviations returns a copy of the parser marking abbreviations in
+// the lines it parses
+func (p *Parser) withAbbreviations(abbreviations map[string]string) *Parser {
+	q := *p
+	q.abbreviations = abbreviations
+	q.abbreviationWords = abbreviationWords(abbreviations)
+	return &q
+}
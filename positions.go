@@ -0,0 +1,223 @@
+package markdownrenderer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Position is a location in the parsed input: a byte offset, and the line
+// and column it falls on, both counted from 1. Columns count bytes.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Range spans the source of an element, from Start to End excluded. Nodes
+// that have no source, like the text generated for cross-references, get
+// an empty range where they would have been.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Source is text taken from the parsed input, such as a block or the
+// content of a quote, along with where each of its lines starts in the
+// input. Block parsers strip prefixes, like quote markers, from the lines
+// they join, so offsets are kept line by line. Parsers build elements from
+// sources, which gives every element the range of its syntax.
+type Source struct {
+	Text  string
+	lines []sourceLine
+	// the offsets the lines of the whole input start at
+	lineStarts []int
+}
+
+// sourceLine is where a line starts within the text of a source and within
+// the input
+type sourceLine struct {
+	at     int
+	offset int
+}
+
+// NewSource returns the source of a whole input
+func NewSource(text string) Source {
+	s := Source{Text: text, lines: []sourceLine{{0, 0}}, lineStarts: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			s.lines = append(s.lines, sourceLine{i + 1, i + 1})
+			s.lineStarts = append(s.lineStarts, i+1)
+		}
+	}
+	return s
+}
+
+// Offset returns the offset in the input of the byte at i in Text, or of
+// the end of Text when i is its length
+func (s Source) Offset(i int) int {
+	if len(s.lines) == 0 {
+		return i
+	}
+	line := s.lines[s.lineAt(i)]
+	return line.offset + i - line.at
+}
+
+// lineAt returns the index of the line holding the byte at i
+func (s Source) lineAt(i int) int {
+	return sort.Search(len(s.lines), func(l int) bool {
+		return s.lines[l].at > i
+	}) - 1
+}
+
+// index returns where the byte at offset in the input is in Text. It is the
+// inverse of Offset.
+func (s Source) index(offset int) int {
+	if len(s.lines) == 0 {
+		return offset
+	}
+	l := max(sort.Search(len(s.lines), func(l int) bool {
+		return s.lines[l].offset > offset
+	})-1, 0)
+	return min(s.lines[l].at+offset-s.lines[l].offset, len(s.Text))
+}
+
+// Position returns the position in the input of the byte at i in Text
+func (s Source) Position(i int) Position {
+	offset := s.Offset(i)
+	if len(s.lineStarts) == 0 {
+		return Position{Offset: offset, Line: 1, Column: offset + 1}
+	}
+	line := sort.Search(len(s.lineStarts), func(l int) bool {
+		return s.lineStarts[l] > offset
+	})
+	return Position{Offset: offset, Line: line, Column: offset - s.lineStarts[line-1] + 1}
+}
+
+// Range returns the range Text spans in the input
+func (s Source) Range() Range {
+	return Range{Start: s.Position(0), End: s.Position(len(s.Text))}
+}
+
+// Slice returns the source of Text[start:end]
+func (s Source) Slice(start, end int) Source {
+	slice := Source{Text: s.Text[start:end], lineStarts: s.lineStarts}
+	if len(s.lines) == 0 {
+		return slice
+	}
+	slice.lines = []sourceLine{{0, s.Offset(start)}}
+	for _, line := range s.lines[s.lineAt(start)+1:] {
+		if line.at > end {
+			break
+		}
+		slice.lines = append(slice.lines, sourceLine{line.at - start, line.offset})
+	}
+	return slice
+}
+
+// within returns the part of s an element built from it spans
+func (s Source) within(r Range) Source {
+	return s.Slice(s.index(r.Start.Offset), max(s.index(r.End.Offset), s.index(r.Start.Offset)))
+}
+
+// Lines splits the source into its lines
+func (s Source) Lines() []Source {
+	lines := []Source{}
+	start := 0
+	for i := 0; i < len(s.Text); i++ {
+		if s.Text[i] == '\n' {
+			lines = append(lines, s.Slice(start, i))
+			start = i + 1
+		}
+	}
+	return append(lines, s.Slice(start, len(s.Text)))
+}
+
+// TrimPrefix returns the source without prefix, when it starts with it
+func (s Source) TrimPrefix(prefix string) Source {
+	if !strings.HasPrefix(s.Text, prefix) {
+		return s
+	}
+	return s.Slice(len(prefix), len(s.Text))
+}
+
+// TrimSpace returns the source without leading and trailing white space
+func (s Source) TrimSpace() Source {
+	start := len(s.Text) - len(strings.TrimLeftFunc(s.Text, unicode.IsSpace))
+	end := len(strings.TrimRightFunc(s.Text, unicode.IsSpace))
+	return s.Slice(start, max(start, end))
+}
+
+// withText returns a source holding text in place of the text of s, such as
+// after unescaping it, with the offsets of the lines of s
+func (s Source) withText(text string) Source {
+	s.Text = text
+	return s
+}
+
+// joinSources joins sources with separator, as strings.Join does. Lines
+// the separator adds start right after the source before them.
+func joinSources(sources []Source, separator string) Source {
+	if len(sources) == 0 {
+		return Source{}
+	}
+	joined := Source{lineStarts: sources[0].lineStarts}
+	text := new(strings.Builder)
+	for i, s := range sources {
+		if i > 0 {
+			end := sources[i-1].Offset(len(sources[i-1].Text))
+			for j := 0; j < len(separator); j++ {
+				if separator[j] == '\n' {
+					end++
+					joined.lines = append(joined.lines, sourceLine{text.Len() + j + 1, end})
+				}
+			}
+			text.WriteString(separator)
+			// the next source starts the last line the separator adds
+			if len(joined.lines) > 0 && joined.lines[len(joined.lines)-1].at == text.Len() {
+				joined.lines = joined.lines[:len(joined.lines)-1]
+			}
+		}
+		for _, line := range s.lines {
+			joined.lines = append(joined.lines, sourceLine{text.Len() + line.at, line.offset})
+		}
+		text.WriteString(s.Text)
+	}
+	joined.Text = text.String()
+	return joined
+}
+
+// Element returns an element for node spanning the source, with children.
+// When none are given, the element holds those of node, spanning the
+// source as well.
+func (s Source) Element(node Node, children ...*Element) *Element {
+	r := s.Range()
+	if len(children) == 0 {
+		for _, child := range node.Children() {
+			children = append(children, s.Element(child))
+		}
+	}
+	return newElement(node, r, children)
+}
+
+func newElement(node Node, r Range, children []*Element) *Element {
+	element := &Element{Node: node, Range: r, Children: children}
+	for _, child := range children {
+		child.Parent = element
+	}
+	return element
+}
+
+// spanning returns an element for node spanning its children
+func spanning(node Node, children ...*Element) *Element {
+	r := Range{}
+	if len(children) > 0 {
+		r = Range{Start: children[0].Range.Start, End: children[len(children)-1].Range.End}
+	}
+	return newElement(node, r, children)
+}
+
+// emptyAt returns the empty range at the start of r
+func emptyAt(r Range) Range {
+	return Range{Start: r.Start, End: r.Start}
+}
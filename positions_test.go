@@ -0,0 +1,107 @@
+package markdownrenderer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "inline nodes",
+			input: "Some **bold** and [a *link*](x.com){.ext}\nsecond line",
+			expected: []string{
+				"document 1:1-2:12", "paragraph 1:1-2:12",
+				"plain 1:1-1:6", "bold 1:6-1:14", "plain 1:14-1:19",
+				"hyperlink 1:19-1:42", "plain 1:20-1:22", "italic 1:22-1:28",
+				"plain 1:42-2:12",
+			},
+		},
+		{
+			name:  "blocks",
+			input: "# Title {#t}\n\n\n> quoted\n> more\n\n- one\n- `c`",
+			expected: []string{
+				"document 1:1-8:6", "header 1:1-1:13", "plain 1:3-1:8",
+				"quote 4:1-5:7", "plain 4:3-5:7",
+				"unordered_list 7:1-8:6", "unordered_item 7:1-7:6", "plain 7:3-7:6", "unordered_item 8:1-8:6", "inline_code 8:3-8:6",
+			},
+		},
+		{
+			name:  "nested blocks",
+			input: ":::note Heads *up*\nbody ![img](i.png \"T\")\n:::",
+			expected: []string{
+				"document 1:1-3:4", "admonition 1:1-3:4",
+				"admonition_title 1:9-1:19", "plain 1:9-1:15", "italic 1:15-1:19",
+				"paragraph 2:1-2:23", "plain 2:1-2:6", "image 2:6-2:23", "plain 2:8-2:11",
+			},
+		},
+		{
+			name:  "definitions and abbreviations",
+			input: "*[API]: Application Programming Interface\nTerm\n: An API\n  more",
+			expected: []string{
+				"document 1:1-4:7", "definition_list 2:1-4:7", "definition_entry 2:1-4:7",
+				"definition_term 2:1-2:5", "plain 2:1-2:5",
+				"definition_description 3:1-4:7", "plain 3:3-3:6", "abbreviation 3:6-3:9", "plain 3:9-4:7",
+			},
+		},
+		{
+			name:  "figure captions",
+			input: "![alt](a.png \"The *title*\"){#fig:a}\n\nSee [](#fig:a).",
+			expected: []string{
+				"document 1:1-3:16", "figure 1:1-1:36", "image 1:1-1:36", "plain 1:3-1:6",
+				"figure_caption 1:15-1:26", "plain 1:15-1:19", "italic 1:19-1:26",
+				"paragraph 3:1-3:16", "plain 3:1-3:5", "hyperlink 3:5-3:15", "plain 3:6-3:6", "italic 3:6-3:6", "plain 3:15-3:16",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				Extensions = 0
+			}()
+			Extensions = EXTFIGURES
+			got := []string{}
+			Walk(Parse(tt.input), VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+				r := e.Range
+				got = append(got, fmt.Sprintf("%s %d:%d-%d:%d", e.Kind(), r.Start.Line, r.Start.Column, r.End.Line, r.End.Column))
+				return WALKCONTINUE
+			}})
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("Parse(%q) ranges\n  got:      %v\n  expected: %v\n  Diff:     %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestParsePositionsOffsets(t *testing.T) {
+	input := "a\n\n[x](y)"
+	link := Parse(input).Children[1].Children[0]
+	expected := Range{Start: Position{Offset: 3, Line: 3, Column: 1}, End: Position{Offset: 9, Line: 3, Column: 7}}
+	if diff := cmp.Diff(link.Range, expected); diff != "" {
+		t.Errorf("Parse(%q) link range\n  got:      %v\n  expected: %v\n  Diff:     %s", input, link.Range, expected, diff)
+	}
+}
+
+// the typographer changes the length of the text, which keeps the range of
+// its source
+func TestParsePositionsTypographer(t *testing.T) {
+	defer func() {
+		Extensions = 0
+	}()
+	Extensions = EXTTYPOGRAPHER
+	input := "Wait... \"what\" -- *no*"
+	plain := Parse(input).Children[0].Children[0]
+	expected := Range{Start: Position{Offset: 0, Line: 1, Column: 1}, End: Position{Offset: 18, Line: 1, Column: 19}}
+	if plain.Node != Plain("Wait… “what” – ") {
+		t.Errorf("Parse(%q) plain node = %q", input, plain.Node)
+	}
+	if diff := cmp.Diff(plain.Range, expected); diff != "" {
+		t.Errorf("Parse(%q) plain range\n  got:      %v\n  expected: %v\n  Diff:     %s", input, plain.Range, expected, diff)
+	}
+}
@@ -0,0 +1,112 @@
+package markdownrenderer
+
+import "regexp"
+
+const REFERENCEREGEX = "@([A-Za-z0-9][A-Za-z0-9-]*)|(?:([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+))?#([0-9]+)\\b|\\b([0-9a-fA-F]{7,40})\\b"
+
+type ReferenceKind int
+
+const (
+	MENTIONREFERENCE ReferenceKind = iota
+	ISSUEREFERENCE
+	COMMITREFERENCE
+)
+
+// Reference is a mention, an issue or a commit found in plain text. Value
+// holds the user name, the issue number or the commit SHA, and Repository
+// the "org/repo" prefix of cross-repository issues.
+type Reference struct {
+	Kind       ReferenceKind
+	Text       string
+	Repository string
+	Value      string
+}
+
+// ReferenceURL turns references into links. It is called for every
+// reference found in plain text and returns the URL to link to, or an empty
+// string to leave the reference as text. References are not parsed at all
+// while it is nil.
+var ReferenceURL func(ref Reference) string
+
+var referencePattern = regexp.MustCompile(REFERENCEREGEX)
+var digitPattern = regexp.MustCompile("[0-9]")
+var hexLetterPattern = regexp.MustCompile("[a-fA-F]")
+
+// ReferenceParser links references in plain text. It runs after the other
+// inline passes, so code spans and existing links are never touched.
+func ReferenceParser(line string) []Node {
+	return nodesOf(currentParser().parseReferences(NewSource(line)))
+}
+
+func (p *Parser) parseReferences(line Source) []*Element {
+	if p.options.ReferenceURL == nil {
+		return []*Element{plainElement(line)}
+	}
+	elements := []*Element{}
+
+	last := 0
+	for _, match := range referencePattern.FindAllStringSubmatchIndex(line.Text, -1) {
+		start, end := match[0], match[1]
+		if start > 0 && !isReferenceBoundary(line.Text[start-1]) {
+			continue
+		}
+		ref, isRef := newReference(line.Text, match)
+		if !isRef {
+			continue
+		}
+		link := p.options.ReferenceURL(ref)
+		if len(link) == 0 {
+			continue
+		}
+		if start > last {
+			elements = append(elements, plainElement(line.Slice(last, start)))
+		}
+		source := line.Slice(start, end)
+		label := source.Element(Plain(ref.Text))
+		if ref.Kind == COMMITREFERENCE {
+			label = source.Slice(0, 7).Element(Plain(ref.Value[:7]))
+		}
+		elements = append(elements, source.Element(Hyperlink{Link: link}, label))
+		last = end
+	}
+	if last < len(line.Text) {
+		elements = append(elements, plainElement(line.Slice(last, len(line.Text))))
+	}
+
+	return elements
+}
+
+func newReference(line string, match []int) (Reference, bool) {
+	group := func(i int) string {
+		if match[2*i] < 0 {
+			return ""
+		}
+		return line[match[2*i]:match[2*i+1]]
+	}
+	ref := Reference{Text: line[match[0]:match[1]]}
+	switch {
+	case len(group(1)) > 0:
+		ref.Kind = MENTIONREFERENCE
+		ref.Value = group(1)
+	case len(group(3)) > 0:
+		ref.Kind = ISSUEREFERENCE
+		ref.Repository = group(2)
+		ref.Value = group(3)
+	default:
+		// hexadecimal words such as "defaced" and plain numbers are not
+		// commits
+		sha := group(4)
+		if !digitPattern.MatchString(sha) || !hexLetterPattern.MatchString(sha) {
+			return ref, false
+		}
+		ref.Kind = COMMITREFERENCE
+		ref.Value = sha
+	}
+
+	return ref, true
+}
+
+// references must not be glued to a preceding word, path or address
+func isReferenceBoundary(c byte) bool {
+	return !isLetter(c) && !isDigit(c) && c != '_' && c != '/' && c != '@' && c != '.' && c != '-'
+}
@@ -0,0 +1,153 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testReferenceURL(ref Reference) string {
+	switch ref.Kind {
+	case MENTIONREFERENCE:
+		return "https://github.com/" + ref.Value
+	case ISSUEREFERENCE:
+		if len(ref.Repository) == 0 {
+			return "https://github.com/org/repo/issues/" + ref.Value
+		}
+		return "https://github.com/" + ref.Repository + "/issues/" + ref.Value
+	case COMMITREFERENCE:
+		return "https://github.com/org/repo/commit/" + ref.Value
+	}
+	return ""
+}
+
+func TestReferenceParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Node
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: []Node{},
+		},
+		{
+			name:     "plain text only",
+			input:    "hello world",
+			expected: []Node{Plain("hello world")},
+		},
+		{
+			name:     "mention",
+			input:    "thanks @octo-cat!",
+			expected: []Node{Plain("thanks "), Hyperlink{Content: []Node{Plain("@octo-cat")}, Link: "https://github.com/octo-cat"}, Plain("!")},
+		},
+		{
+			name:     "issue",
+			input:    "fixes #123",
+			expected: []Node{Plain("fixes "), Hyperlink{Content: []Node{Plain("#123")}, Link: "https://github.com/org/repo/issues/123"}},
+		},
+		{
+			name:     "cross repository issue",
+			input:    "see other/lib#45.",
+			expected: []Node{Plain("see "), Hyperlink{Content: []Node{Plain("other/lib#45")}, Link: "https://github.com/other/lib/issues/45"}, Plain(".")},
+		},
+		{
+			name:     "commit",
+			input:    "in a1b2c3d4e5f6",
+			expected: []Node{Plain("in "), Hyperlink{Content: []Node{Plain("a1b2c3d")}, Link: "https://github.com/org/repo/commit/a1b2c3d4e5f6"}},
+		},
+		{
+			name:     "email is not a mention",
+			input:    "mail me@example.com",
+			expected: []Node{Plain("mail me@example.com")},
+		},
+		{
+			name:     "hexadecimal word is not a commit",
+			input:    "a defaced wall",
+			expected: []Node{Plain("a defaced wall")},
+		},
+		{
+			name:     "number is not a commit",
+			input:    "call 1234567",
+			expected: []Node{Plain("call 1234567")},
+		},
+		{
+			name:     "short hexadecimal is not a commit",
+			input:    "color a1b2c3",
+			expected: []Node{Plain("color a1b2c3")},
+		},
+		{
+			name:     "anchor inside word is not an issue",
+			input:    "C#1 code",
+			expected: []Node{Plain("C#1 code")},
+		},
+	}
+
+	defer func() { ReferenceURL = nil }()
+	ReferenceURL = testReferenceURL
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ReferenceParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("ReferenceParser(%q)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestReferenceParserDisabled(t *testing.T) {
+	got := ReferenceParser("@user fixed #1")
+	expected := []Node{Plain("@user fixed #1")}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("ReferenceParser without ReferenceURL\n  got:      %v\n  expected: %v\n  Diff:      %s", got, expected, diff)
+	}
+}
+
+func TestReferenceURLSkip(t *testing.T) {
+	defer func() { ReferenceURL = nil }()
+	ReferenceURL = func(ref Reference) string {
+		if ref.Kind == MENTIONREFERENCE {
+			return ""
+		}
+		return testReferenceURL(ref)
+	}
+	got := ReferenceParser("@user fixed #1")
+	expected := []Node{Plain("@user fixed "), Hyperlink{Content: []Node{Plain("#1")}, Link: "https://github.com/org/repo/issues/1"}}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("ReferenceParser with skipped mentions\n  got:      %v\n  expected: %v\n  Diff:      %s", got, expected, diff)
+	}
+}
+
+func TestReferencesUntouched(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Node
+	}{
+		{
+			name:     "code span",
+			input:    "run `git show a1b2c3d` for #2",
+			expected: []Node{Plain("run "), InlineCode("git show a1b2c3d"), Plain(" for "), Hyperlink{Content: []Node{Plain("#2")}, Link: "https://github.com/org/repo/issues/2"}},
+		},
+		{
+			name:     "existing link",
+			input:    "[@user](https://example.com)",
+			expected: []Node{Hyperlink{Content: []Node{Plain("@user")}, Link: "https://example.com"}},
+		},
+	}
+
+	defer func() { ReferenceURL = nil }()
+	ReferenceURL = testReferenceURL
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LineParser(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("LineParser(%q)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+	if got := MarkdownToHTML("```\n#1 @user\n```").HTMLRender(); got != "<div><pre><code>\n#1 @user\n</code></pre></div>" {
+		t.Errorf("references were linked inside a code block: %q", got)
+	}
+}
@@ -0,0 +1,311 @@
+package markdownrenderer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Renderer renders a document tree, to a string or to a writer
+type Renderer interface {
+	Render(element *Element) string
+	RenderTo(w io.Writer, element *Element) error
+}
+
+// RenderFunc writes an element of one kind to w. Children are rendered
+// through w, so that the functions registered for their kinds apply at any
+// depth.
+type RenderFunc func(w *HTMLWriter, element *Element)
+
+// HTMLRenderer renders document trees to HTML with a function per node
+// kind. Kinds without one, custom nodes included, are rendered by their
+// ToHTML method.
+type HTMLRenderer struct {
+	funcs map[NodeKind]RenderFunc
+	// options are those of the converter using the renderer, or nil for the
+	// package-level variables
+	options *Options
+}
+
+// NewHTMLRenderer returns a renderer with the default function of every
+// kind, which renders it like its ToHTML method
+func NewHTMLRenderer() *HTMLRenderer {
+	r := &HTMLRenderer{funcs: map[NodeKind]RenderFunc{}}
+	for _, kind := range []NodeKind{
+		DOCUMENTNODE, HYPERLINKNODE, IMAGENODE, CROSSREFERENCENODE,
+		HEADERNODE, PARAGRAPHNODE, QUOTENODE, ORDEREDITEMNODE, UNORDEREDITEMNODE,
+		DEFINITIONTERMNODE, DEFINITIONDESCRIPTIONNODE, ADMONITIONTITLENODE, FIGURECAPTIONNODE,
+	} {
+		r.funcs[kind] = renderContainer
+	}
+	r.funcs[WIKILINKNODE] = renderWikiLink
+	r.funcs[ORDEREDLISTNODE] = renderOrderedList
+	r.funcs[UNORDEREDLISTNODE] = renderUnorderedList
+	r.funcs[DEFINITIONLISTNODE] = renderDefinitionList
+	r.funcs[DEFINITIONENTRYNODE] = renderDefinitionEntry
+	r.funcs[ADMONITIONNODE] = renderAdmonition
+	r.funcs[FIGURENODE] = renderFigure
+	r.funcs[TABLENODE] = renderTable
+	r.funcs[TABLEHEADERNODE] = renderTableRow
+	r.funcs[TABLEROWNODE] = renderTableRow
+	r.funcs[TABLECELLNODE] = renderTableCell
+
+	return r
+}
+
+// Register sets the render function of kind, replacing the current one
+func (r *HTMLRenderer) Register(kind NodeKind, render RenderFunc) {
+	r.funcs[kind] = render
+}
+
+// Lookup returns the render function of kind, so that overrides can fall
+// back on it. Kinds without one are rendered by their ToHTML method.
+func (r *HTMLRenderer) Lookup(kind NodeKind) RenderFunc {
+	if render, isR := r.funcs[kind]; isR {
+		return render
+	}
+	return renderNode
+}
+
+func (r *HTMLRenderer) withOptions(options *Options) Renderer {
+	renderer := *r
+	renderer.options = options
+	return &renderer
+}
+
+// Options returns the options the renderer renders with
+func (r *HTMLRenderer) Options() Options {
+	if r.options == nil {
+		return currentOptions()
+	}
+	return *r.options
+}
+
+func (r *HTMLRenderer) Render(element *Element) string {
+	builder := new(strings.Builder)
+	r.writer(builder).Render(element)
+
+	return builder.String()
+}
+
+// RenderTo writes the HTML of element to w through a buffer, and returns the
+// first error writing to w, at which rendering stops
+func (r *HTMLRenderer) RenderTo(w io.Writer, element *Element) error {
+	buffered := bufio.NewWriter(w)
+	output := r.writer(buffered)
+	output.Render(element)
+	if output.err != nil {
+		return output.err
+	}
+
+	return buffered.Flush()
+}
+
+func (r *HTMLRenderer) writer(out io.Writer) *HTMLWriter {
+	options := r.Options()
+	w := newHTMLWriter(out, &options)
+	w.renderer = r
+	return w
+}
+
+// Render writes element with the render function of its kind
+func (w *HTMLWriter) Render(element *Element) {
+	if w.err != nil {
+		return
+	}
+	if w.renderer == nil {
+		renderNode(w, element)
+		return
+	}
+	w.renderer.Lookup(element.Kind())(w, element)
+}
+
+// RenderChildren writes the children of element one after the other
+func (w *HTMLWriter) RenderChildren(element *Element) {
+	for _, child := range element.Children {
+		w.Render(child)
+	}
+}
+
+// To returns a writer rendering like w to out, for render functions that
+// change what others render. Errors writing to out are returned by its Err.
+func (w *HTMLWriter) To(out io.Writer) *HTMLWriter {
+	to := newHTMLWriter(out, w.options)
+	to.renderer = w.renderer
+	return to
+}
+
+// renderedElement is a child element in place of its node, so that
+// containers are rendered by their own ToHTML method around it. It is
+// rendered by the functions of the writer it is written to.
+type renderedElement struct {
+	element *Element
+}
+
+func (t renderedElement) ToHTML() HTMLNode {
+	return t
+}
+func (t renderedElement) RenderHTML(w *HTMLWriter) {
+	w.Render(t.element)
+}
+func (t renderedElement) HTMLRender() string {
+	return NewHTMLRenderer().Render(t.element)
+}
+func (t renderedElement) Kind() NodeKind {
+	return ""
+}
+func (t renderedElement) Children() []Node {
+	return nil
+}
+
+// renderedChildren returns the children of element as HTML nodes
+func renderedChildren(element *Element) []HTMLNode {
+	children := []HTMLNode{}
+	for _, child := range element.Children {
+		children = append(children, renderedElement{child})
+	}
+	return children
+}
+
+// htmlAttributes returns the attributes the ToHTML method of the node of
+// element gives the HTML element, for the templates shared with the HTML
+// nodes
+func htmlAttributes(element *Element) Attributes {
+	attributes, _ := HTMLAttributes(withChildren(element.Node, nil).ToHTML())
+	return attributes
+}
+
+func renderNode(w *HTMLWriter, element *Element) {
+	w.WriteNode(element.Build().ToHTML())
+}
+
+// renderContainer renders containers whose children are of any kind
+func renderContainer(w *HTMLWriter, element *Element) {
+	children := []Node{}
+	for _, child := range element.Children {
+		children = append(children, renderedElement{child})
+	}
+	w.WriteNode(withChildren(element.Node, children).ToHTML())
+}
+
+// renderWikiLink resolves wiki links with the resolver of the renderer
+func renderWikiLink(w *HTMLWriter, element *Element) {
+	link := element.Node.(WikiLink)
+	url, exists := resolveWikiLink(w.options.WikiResolver, link.Page)
+	w.WriteNode(HTMLWikiLink{Content: renderedChildren(element), Link: url, Exists: exists})
+}
+
+func renderOrderedList(w *HTMLWriter, element *Element) {
+	orderedListTemplate(w, htmlAttributes(element), func() {
+		w.RenderChildren(element)
+	})
+}
+
+func renderUnorderedList(w *HTMLWriter, element *Element) {
+	unorderedListTemplate(w, htmlAttributes(element), func() {
+		w.RenderChildren(element)
+	})
+}
+
+func renderDefinitionList(w *HTMLWriter, element *Element) {
+	definitionListTemplate(w, htmlAttributes(element), func() {
+		w.RenderChildren(element)
+	})
+}
+
+func renderDefinitionEntry(w *HTMLWriter, element *Element) {
+	w.RenderChildren(element)
+}
+
+func renderAdmonition(w *HTMLWriter, element *Element) {
+	admonition := element.Node.(Admonition)
+	title := &Element{Node: AdmonitionTitle{}, Parent: element}
+	content := []*Element{}
+	for _, child := range element.Children {
+		if child.Kind() != ADMONITIONTITLENODE {
+			content = append(content, child)
+		} else if len(child.Children) > 0 {
+			title = child
+		}
+	}
+	if len(title.Children) == 0 {
+		title.Children = []*Element{{Node: Plain(admonitionTypeTitle(admonition.Type)), Parent: title}}
+	}
+	admonitionTemplate(w, admonition.Type, htmlAttributes(element), func() {
+		w.Render(title)
+	}, func() {
+		for _, child := range content {
+			w.Render(child)
+		}
+	})
+}
+
+func renderFigure(w *HTMLWriter, element *Element) {
+	figure := element.Node.(Figure)
+	var image, caption *Element
+	for _, child := range element.Children {
+		if child.Kind() == IMAGENODE {
+			image = child
+		} else if child.Kind() == FIGURECAPTIONNODE && len(child.Children) > 0 {
+			caption = child
+		}
+	}
+	if figure.Number > 0 {
+		// the number is rendered as part of the caption
+		numbered := &Element{Node: FigureCaption{}, Parent: element}
+		if caption != nil {
+			numbered.Node = caption.Node
+			numbered.Range = caption.Range
+			numbered.Children = caption.Children
+		}
+		prefix := figureNumber(w.options, figure.Number, caption != nil)
+		numbered.Children = append([]*Element{{Node: Plain(prefix), Parent: numbered}}, numbered.Children...)
+		caption = numbered
+	}
+	var writeCaption func()
+	if caption != nil {
+		writeCaption = func() {
+			w.Render(caption)
+		}
+	}
+	figureTemplate(w, htmlAttributes(element), func() {
+		if image != nil {
+			w.Render(image)
+		}
+	}, writeCaption)
+}
+
+func renderTable(w *HTMLWriter, element *Element) {
+	var header *Element
+	rows := []*Element{}
+	for _, child := range element.Children {
+		if child.Kind() == TABLEHEADERNODE {
+			header = child
+		} else {
+			rows = append(rows, child)
+		}
+	}
+	tableTemplate(w, htmlAttributes(element), func() {
+		if header != nil {
+			w.Render(header)
+		}
+	}, func() {
+		for _, row := range rows {
+			w.Render(row)
+		}
+	})
+}
+
+func renderTableRow(w *HTMLWriter, element *Element) {
+	tableRowTemplate(w, func() {
+		w.RenderChildren(element)
+	})
+}
+
+// renderTableCell renders the cells of the header of a table as such
+func renderTableCell(w *HTMLWriter, element *Element) {
+	header := element.Parent != nil && element.Parent.Kind() == TABLEHEADERNODE
+	tableCellTemplate(w, header, func() {
+		w.RenderChildren(element)
+	})
+}
@@ -0,0 +1,154 @@
+package markdownrenderer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHTMLRendererDefault(t *testing.T) {
+	inputs := []string{
+		"",
+		"# **Bold** Title {#top .lead}\n\nSome *text* with `code`, a [link](x.com) and ![alt](a.png).",
+		"> quoted [[Wiki Page]]\n\n---\n\n```\ncode\n```",
+		"1. one\n2. **two**\n\n* a\n* b",
+		"Apple\nPomme\n: A fruit\n: A company",
+		":::note\nRead *this*\n:::\n\n:::warning Careful\nfirst\n:::",
+		"See @fig:a and @missing.\n\n![First](a.png){#fig:a}\n\n![](b.png)\n\n$$\nx^2\n$$ {#eq:x}",
+		"![Wide](c.png){#fig:c .wide width=3}\n\n:::tip\n:::",
+		"See @tbl:a.\n\n{#tbl:a}\n| A | *B* |\n| --- | :-: |\n| 1 | a \\| b |\n| 2 |",
+	}
+
+	defer func() {
+		Extensions = 0
+		FigureNumbering = false
+	}()
+	Extensions = EXTFIGURES | EXTCROSSREFERENCES
+	FigureNumbering = true
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			got := RenderMarkdown(input, NewHTMLRenderer())
+			expected := MarkdownToHTML(input).HTMLRender()
+			if got != expected {
+				t.Errorf("RenderMarkdown(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+			}
+		})
+	}
+}
+
+func TestHTMLRendererRegister(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     NodeKind
+		render   func(previous RenderFunc) RenderFunc
+		input    string
+		expected string
+	}{
+		{
+			name: "header",
+			kind: HEADERNODE,
+			render: func(previous RenderFunc) RenderFunc {
+				return func(w *HTMLWriter, e *Element) {
+					header := e.Node.(Header)
+					fmt.Fprintf(w, "<h%d class='title'>", header.Level+1)
+					w.RenderChildren(e)
+					fmt.Fprintf(w, "</h%d>", header.Level+1)
+				}
+			},
+			input:    "# Title *here*\n\ntext",
+			expected: "<div><h2 class='title'>Title <em>here</em></h2><p>text</p></div>",
+		},
+		{
+			name: "nested leaf",
+			kind: BOLDNODE,
+			render: func(previous RenderFunc) RenderFunc {
+				return func(w *HTMLWriter, e *Element) {
+					w.WriteString("<b>" + string(e.Node.(Bold)) + "</b>")
+				}
+			},
+			input:    "* a **b**\n\n> [**c**](x.com)",
+			expected: "<div><ul><li>a <b>b</b></li></ul><blockquote><a href='x.com'><b>c</b></a></blockquote></div>",
+		},
+		{
+			name: "wrapping the default",
+			kind: PARAGRAPHNODE,
+			render: func(previous RenderFunc) RenderFunc {
+				return func(w *HTMLWriter, e *Element) {
+					w.WriteString("<section>")
+					previous(w, e)
+					w.WriteString("</section>")
+				}
+			},
+			input:    "one\n\ntwo",
+			expected: "<div><section><p>one</p></section><section><p>two</p></section></div>",
+		},
+		{
+			name: "kind without default",
+			kind: PLAINNODE,
+			render: func(previous RenderFunc) RenderFunc {
+				return func(w *HTMLWriter, e *Element) {
+					plain := new(strings.Builder)
+					previous(w.To(plain), e)
+					w.WriteString(strings.ToUpper(plain.String()))
+				}
+			},
+			input:    ":::tip\nbe *kind*\n:::",
+			expected: "<div><div class='admonition tip'><p class='admonition-title'>TIP</p><p>BE <em>kind</em></p></div></div>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := NewHTMLRenderer()
+			renderer.Register(tt.kind, tt.render(renderer.Lookup(tt.kind)))
+			got := RenderMarkdown(tt.input, renderer)
+			if got != tt.expected {
+				t.Errorf("RenderMarkdown(%q)\n  got:      %q\n  expected: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderTo(t *testing.T) {
+	input := "# Title\n\n* a **b**\n\n:::note\nnoted\n:::\n\n![Cap](c.png){#fig:c}"
+	tests := []struct {
+		name      string
+		converter *Converter
+	}{
+		{name: "html", converter: NewConverter(WithExtensions(EXTFIGURES), WithFigureNumbering(true))},
+		{name: "markdown", converter: NewConverter(WithRenderer(NewMarkdownRenderer(0)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, _ := tt.converter.Parse(input)
+			builder := new(strings.Builder)
+			if err := tt.converter.RenderTo(builder, document); err != nil {
+				t.Fatalf("Converter.RenderTo() error = %v", err)
+			}
+			if got, expected := builder.String(), tt.converter.Render(document); got != expected {
+				t.Errorf("Converter.RenderTo()\n  got:      %q\n  expected: %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestRenderToError(t *testing.T) {
+	paragraphs := 2000
+	rendered := 0
+	renderer := NewHTMLRenderer()
+	previous := renderer.Lookup(PARAGRAPHNODE)
+	renderer.Register(PARAGRAPHNODE, func(w *HTMLWriter, e *Element) {
+		rendered++
+		previous(w, e)
+	})
+	document := Parse(strings.Repeat("some *text* here\n\n", paragraphs))
+
+	err := renderer.RenderTo(&failingWriter{limit: 100}, document)
+	if !errors.Is(err, errWrite) {
+		t.Errorf("got: %v, expected: %v", err, errWrite)
+	}
+	if rendered >= paragraphs {
+		t.Errorf("rendered %d paragraphs after the write error, expected rendering to stop", rendered)
+	}
+}
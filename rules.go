@@ -0,0 +1,127 @@
+package markdownrenderer
+
+import "strings"
+
+// BlockRule recognises blocks of one syntax. Parse reports whether block
+// is of that syntax and returns its element, parsing nested content with p.
+// Elements are built from the source of their syntax, as in
+// block.Element(node, children...), to span it. Rules are tried by
+// decreasing Priority, in order of declaration on ties, and only on blocks
+// whose first character is one of Triggers, unless Triggers is empty. Blocks
+// no rule recognises are paragraphs.
+type BlockRule struct {
+	Name     string
+	Triggers string
+	Priority int
+	Parse    func(p *Parser, block Source) (*Element, bool)
+}
+
+// InlineRule parses the syntax of one kind of inline node. Parse splits a
+// line of text into elements, in the dialect of p, returning text it does
+// not recognise as Plain elements, which the following rules parse in turn.
+// Every element is built from the part of line it was parsed from, as in
+// line.Slice(start, end).Element(node). Rules run by decreasing Priority, in
+// order of declaration on ties, so a rule sees the text left over by the
+// rules before it.
+type InlineRule struct {
+	Name     string
+	Priority int
+	Parse    func(p *Parser, line Source) []*Element
+}
+
+// BlockRules are the block syntaxes recognised by BlockParser. Built-in
+// rules have priorities from 100 to 1000: add rules with a higher priority
+// to take precedence over them, or with a lower one to only parse blocks
+// they do not recognise.
+var BlockRules = defaultBlockRules()
+
+// InlineRules are the inline syntaxes parsed by NodeParser. Built-in rules
+// have priorities from 100 to 800, so that, for instance, a rule with a
+// priority above 200 parses text before emphasis does.
+var InlineRules = defaultInlineRules()
+
+func defaultBlockRules() []BlockRule {
+	return []BlockRule{
+		{Name: "header", Triggers: HEADERPREFIX, Priority: 1000, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if level, isH := isHeader(block.Text); isH {
+				return p.headerify(block, level), true
+			}
+			return nil, false
+		}},
+		{Name: "break", Triggers: BREAKDELIMITER[:1], Priority: 900, Parse: func(p *Parser, block Source) (*Element, bool) {
+			return block.Element(Break(true)), isBreak(block.Text)
+		}},
+		{Name: "code", Triggers: CODEDELIMITER[:1], Priority: 800, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if isCode(block.Text) {
+				return block.Element(codeify(block.Text)), true
+			}
+			return nil, false
+		}},
+		{Name: "display_math", Priority: 700, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if d, isM := p.isDisplayMath(block.Text); isM {
+				return block.Element(mathify(block.Text, d)), true
+			}
+			return nil, false
+		}},
+		{Name: "admonition", Triggers: ADMONITIONFENCE[:1] + ADMONITIONQUOTE, Priority: 600, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if p.isAdmonition(block.Text) {
+				return p.admonitionify(block), true
+			}
+			return nil, false
+		}},
+		{Name: "quote", Triggers: QUOTEPREFIX1[:1] + QUOTEPREFIX2[:1] + QUOTEPREFIX3, Priority: 500, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if del, isQ := isQuote(block.Text); isQ {
+				return p.quoteify(block, del), true
+			}
+			return nil, false
+		}},
+		{Name: "ordered_list", Triggers: ORDEREDPREFIX[:1], Priority: 400, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if isOrderedList(block.Text) {
+				return p.olistify(block), true
+			}
+			return nil, false
+		}},
+		{Name: "unordered_list", Triggers: UNORDEREDPREFIX1[:1] + UNORDEREDPREFIX2[:1], Priority: 300, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if isUnorderedList(block.Text) {
+				return p.ulistify(block), true
+			}
+			return nil, false
+		}},
+		{Name: "table", Priority: 200, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if isTable(block.Text) {
+				return p.tableify(block), true
+			}
+			return nil, false
+		}},
+		{Name: "definition_list", Priority: 100, Parse: func(p *Parser, block Source) (*Element, bool) {
+			if isDefinitionList(block.Text) {
+				return p.definitionify(block), true
+			}
+			return nil, false
+		}},
+	}
+}
+
+func defaultInlineRules() []InlineRule {
+	return []InlineRule{
+		{Name: "math", Priority: 800, Parse: (*Parser).parseMath},
+		{Name: "image", Priority: 700, Parse: (*Parser).parseImages},
+		{Name: "wiki_link", Priority: 600, Parse: (*Parser).parseWikiLinks},
+		{Name: "hyperlink", Priority: 500, Parse: (*Parser).parseHyperlinks},
+		{Name: "cross_reference", Priority: 400, Parse: func(p *Parser, line Source) []*Element {
+			if !p.enabled(EXTCROSSREFERENCES) {
+				return []*Element{plainElement(line)}
+			}
+			return parseCrossReferences(line)
+		}},
+		{Name: "simple", Priority: 200, Parse: (*Parser).parseSimple},
+		{Name: "emoji", Priority: 150, Parse: func(p *Parser, line Source) []*Element {
+			return parseEmoji(line)
+		}},
+		{Name: "reference", Priority: 100, Parse: (*Parser).parseReferences},
+	}
+}
+
+func (r BlockRule) triggered(block string) bool {
+	return len(r.Triggers) == 0 || (len(block) > 0 && strings.IndexByte(r.Triggers, block[0]) >= 0)
+}
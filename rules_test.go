@@ -0,0 +1,112 @@
+package markdownrenderer
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// jiraIssue is a custom node parsed from shortcodes like {{< jira ABC-1 >}}
+type jiraIssue string
+
+func (t jiraIssue) ToHTML() HTMLNode {
+	return HTMLHyperlink{Content: []HTMLNode{HTMLPlain(t)}, Link: "https://jira.example.com/browse/" + string(t)}
+}
+func (t jiraIssue) Kind() NodeKind {
+	return "jira"
+}
+func (t jiraIssue) Children() []Node {
+	return nil
+}
+
+var jiraPattern = regexp.MustCompile(`\{\{< jira ([A-Z]+-[0-9]+) >\}\}`)
+
+func jiraParser(p *Parser, line Source) []*Element {
+	elements := []*Element{}
+	last := 0
+	for _, match := range jiraPattern.FindAllStringSubmatchIndex(line.Text, -1) {
+		if match[0] > last {
+			elements = append(elements, line.Slice(last, match[0]).Element(Plain(line.Text[last:match[0]])))
+		}
+		elements = append(elements, line.Slice(match[0], match[1]).Element(jiraIssue(line.Text[match[2]:match[3]])))
+		last = match[1]
+	}
+	if last < len(line.Text) {
+		elements = append(elements, line.Slice(last, len(line.Text)).Element(Plain(line.Text[last:])))
+	}
+	return elements
+}
+
+func TestRules(t *testing.T) {
+	toc := BlockRule{Name: "toc", Triggers: "[", Priority: 2000, Parse: func(p *Parser, block Source) (*Element, bool) {
+		return block.Element(Break(true)), block.Text == "[TOC]"
+	}}
+	shout := BlockRule{Name: "shout", Priority: 0, Parse: func(p *Parser, block Source) (*Element, bool) {
+		if !strings.HasSuffix(block.Text, "!!") {
+			return nil, false
+		}
+		content := block.Slice(0, len(block.Text)-len("!!"))
+		return block.Element(Header{Level: 1}, p.ParseLine(content)...), true
+	}}
+	tests := []struct {
+		name     string
+		block    []BlockRule
+		inline   []InlineRule
+		input    string
+		expected string
+	}{
+		{
+			name:     "inline rule",
+			inline:   []InlineRule{{Name: "jira", Priority: 250, Parse: jiraParser}},
+			input:    "Fixed in {{< jira ABC-1 >}}, see *notes*.",
+			expected: "<div><p>Fixed in <a href='https://jira.example.com/browse/ABC-1'>ABC-1</a>, see <em>notes</em>.</p></div>",
+		},
+		{
+			name:     "inline rule in nested blocks",
+			inline:   []InlineRule{{Name: "jira", Priority: 250, Parse: jiraParser}},
+			input:    "* {{< jira ABC-1 >}}\n\n> {{< jira XY-22 >}}",
+			expected: "<div><ul><li><a href='https://jira.example.com/browse/ABC-1'>ABC-1</a></li></ul><blockquote><a href='https://jira.example.com/browse/XY-22'>XY-22</a></blockquote></div>",
+		},
+		{
+			name:     "inline rule after simple",
+			inline:   []InlineRule{{Name: "jira", Priority: 150, Parse: jiraParser}},
+			input:    "`{{< jira ABC-1 >}}`",
+			expected: "<div><p><code>{{< jira ABC-1 >}}</code></p></div>",
+		},
+		{
+			name:     "block rule with triggers",
+			block:    []BlockRule{toc},
+			input:    "[TOC]\n\n[TOC] here",
+			expected: "<div><br><p>[TOC] here</p></div>",
+		},
+		{
+			name:     "block rule below built-ins",
+			block:    []BlockRule{shout},
+			input:    "loud!!\n\n* list!!",
+			expected: "<div><h1>loud</h1><ul><li>list!!</li></ul></div>",
+		},
+		{
+			name: "block rule priority over built-ins",
+			block: []BlockRule{{Name: "plain_lists", Triggers: "*", Priority: 1500, Parse: func(p *Parser, block Source) (*Element, bool) {
+				return block.Element(Paragraph{Content: []Node{Plain(block.Text)}}), true
+			}}},
+			input:    "* one\n* two",
+			expected: "<div><p>* one\n* two</p></div>",
+		},
+	}
+
+	blockRules, inlineRules := BlockRules, InlineRules
+	defer func() {
+		BlockRules, InlineRules = blockRules, inlineRules
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			BlockRules = append(append([]BlockRule{}, blockRules...), tt.block...)
+			InlineRules = append(append([]InlineRule{}, inlineRules...), tt.inline...)
+			got := MarkdownToHTML(tt.input).HTMLRender()
+			if got != tt.expected {
+				t.Errorf("MarkdownToHTML(%q)\n  got:      %q\n  expected: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package markdownrenderer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Transformer changes a parsed document before it is rendered, by changing
+// its elements in place or replacing them
+type Transformer interface {
+	Transform(document *Element)
+}
+
+// TransformerFunc adapts a function to the Transformer interface
+type TransformerFunc func(document *Element)
+
+func (f TransformerFunc) Transform(document *Element) {
+	f(document)
+}
+
+// Transform runs transformers on document, in order
+func Transform(document *Element, transformers ...Transformer) {
+	for _, transformer := range transformers {
+		transformer.Transform(document)
+	}
+}
+
+// RewriteImagePaths replaces the path of every image, figures included, with
+// the one returned by rewrite, for instance to serve relative paths from a
+// CDN
+func RewriteImagePaths(rewrite func(path string) string) Transformer {
+	return TransformerFunc(func(document *Element) {
+		Walk(document, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+			if image, isI := e.Node.(Image); isI {
+				image.Path = rewrite(image.Path)
+				e.Node = image
+			}
+			return WALKCONTINUE
+		}})
+	})
+}
+
+// DemoteHeadings lowers the level of every header by levels, or raises it
+// when levels is negative, keeping levels between 1 and 6
+func DemoteHeadings(levels int) Transformer {
+	return TransformerFunc(func(document *Element) {
+		Walk(document, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+			if header, isH := e.Node.(Header); isH {
+				header.Level = min(max(header.Level+levels, 1), 6)
+				e.Node = header
+				return WALKSKIPCHILDREN
+			}
+			return WALKCONTINUE
+		}})
+	})
+}
+
+// StripImages removes images and figures from the document, and the
+// paragraphs left without text
+func StripImages() Transformer {
+	return TransformerFunc(func(document *Element) {
+		images := []*Element{}
+		Walk(document, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+			if e.Kind() == IMAGENODE || e.Kind() == FIGURENODE {
+				images = append(images, e)
+				return WALKSKIPCHILDREN
+			}
+			return WALKCONTINUE
+		}})
+		for _, image := range images {
+			parent := image.Parent
+			image.Remove()
+			if parent != nil && parent.Kind() == PARAGRAPHNODE && isBlank(parent) {
+				parent.Remove()
+			}
+		}
+	})
+}
+
+// NoFollowExternalLinks adds nofollow to the rel of links to other hosts,
+// that is links with a scheme and a host, keeping the other rel tokens
+func NoFollowExternalLinks() Transformer {
+	return TransformerFunc(func(document *Element) {
+		Walk(document, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+			link, isL := e.Node.(Hyperlink)
+			if !isL || !isExternal(link.Link) {
+				return WALKCONTINUE
+			}
+			rel := strings.Fields(link.Attributes["rel"])
+			for _, token := range rel {
+				if token == "nofollow" {
+					return WALKCONTINUE
+				}
+			}
+			link.Attributes = link.Attributes.Clone()
+			link.Attributes["rel"] = strings.Join(append(rel, "nofollow"), " ")
+			e.Node = link
+			return WALKCONTINUE
+		}})
+	})
+}
+
+// isBlank reports whether the children of e are nothing but whitespace
+func isBlank(e *Element) bool {
+	for _, child := range e.Children {
+		if text, isP := child.Node.(Plain); !isP || len(strings.TrimSpace(string(text))) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isExternal(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.IsAbs() && len(u.Host) > 0
+}
@@ -0,0 +1,107 @@
+package markdownrenderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	cdn := RewriteImagePaths(func(path string) string {
+		if isExternal(path) {
+			return path
+		}
+		return "https://cdn.example.com/" + strings.TrimPrefix(path, "/")
+	})
+	tests := []struct {
+		name         string
+		transformers []Transformer
+		extensions   Extension
+		input        string
+		expected     string
+	}{
+		{
+			name:         "image paths",
+			transformers: []Transformer{cdn},
+			input:        "![a](img/a.png) ![b](https://x.com/b.png)\n\n![c](/c.png)",
+			expected:     "<div><p><img src='https://cdn.example.com/img/a.png'>a</img> <img src='https://x.com/b.png'>b</img></p><figure><img src='https://cdn.example.com/c.png'>c</img><figcaption>c</figcaption></figure></div>",
+			extensions:   EXTFIGURES,
+		},
+		{
+			name:         "demoted headings",
+			transformers: []Transformer{DemoteHeadings(1)},
+			input:        "# One {#one}\n\n###### Six",
+			expected:     "<div><h2 id='one'>One</h2><h6>Six</h6></div>",
+		},
+		{
+			name:         "stripped images",
+			transformers: []Transformer{StripImages()},
+			input:        "Look ![a](a.png) ![b](b.png) here\n\n![c](c.png)\n\n* ![d](d.png)",
+			expected:     "<div><p>Look   here</p><ul><li></li></ul></div>",
+			extensions:   EXTFIGURES,
+		},
+		{
+			name:         "paragraphs of images stripped",
+			transformers: []Transformer{StripImages()},
+			input:        "![a](a.png) ![b](b.png)\n\ntext",
+			expected:     "<div><p>text</p></div>",
+		},
+		{
+			name:         "promoted headings",
+			transformers: []Transformer{DemoteHeadings(-2)},
+			input:        "# One\n\n### Three",
+			expected:     "<div><h1>One</h1><h1>Three</h1></div>",
+		},
+		{
+			name:         "headings demoted past six",
+			transformers: []Transformer{DemoteHeadings(10)},
+			input:        "# One",
+			expected:     "<div><h6>One</h6></div>",
+		},
+		{
+			name:         "external links",
+			transformers: []Transformer{NoFollowExternalLinks()},
+			input:        "[in](/docs) [out](https://example.com){.ext} [mail](mailto:a@b.c)",
+			expected:     "<div><p><a href='/docs'>in</a> <a href='https://example.com' class='ext' rel='nofollow'>out</a> <a href='mailto:a@b.c'>mail</a></p></div>",
+		},
+		{
+			name:         "nofollow keeps rel",
+			transformers: []Transformer{NoFollowExternalLinks()},
+			input:        "[x](https://ex.com){rel=noopener} [y](https://ex.com){rel=\"nofollow me\"}",
+			expected:     "<div><p><a href='https://ex.com' rel='noopener nofollow'>x</a> <a href='https://ex.com' rel='nofollow me'>y</a></p></div>",
+		},
+		{
+			name: "declared order",
+			transformers: []Transformer{DemoteHeadings(2), TransformerFunc(func(document *Element) {
+				for _, header := range document.Children {
+					header.Replace(Paragraph{Content: []Node{Plain("level "), Bold(string(rune('0' + header.Node.(Header).Level)))}})
+				}
+			})},
+			input:    "# a\n\n## b",
+			expected: "<div><p>level <strong>3</strong></p><p>level <strong>4</strong></p></div>",
+		},
+	}
+
+	defer func() {
+		Extensions = 0
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Extensions = tt.extensions
+			document := Parse(tt.input)
+			Transform(document, tt.transformers...)
+			got := document.Build().ToHTML().HTMLRender()
+			if got != tt.expected {
+				t.Errorf("Transform(%q)\n  got:      %q\n  expected: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConverterTransformers(t *testing.T) {
+	input := "# Title\n\n![a](a.png)"
+	converter := NewConverter(WithTransformers(StripImages(), DemoteHeadings(1)))
+	expected := "<div><h2>Title</h2></div>"
+	if got, _ := converter.Convert(input); got != expected {
+		t.Errorf("Convert(%q)\n  got:      %q\n  expected: %q", input, got, expected)
+	}
+}
@@ -0,0 +1,117 @@
+package markdownrenderer
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	EMDASHDELIMITER   = "---"
+	ENDASHDELIMITER   = "--"
+	ELLIPSISDELIMITER = "..."
+)
+
+type QuoteStyle struct {
+	DoubleOpen  string
+	DoubleClose string
+	SingleOpen  string
+	SingleClose string
+}
+
+// QuoteStyles holds the curly quotes used by the typographer for each
+// locale. French quotes include the customary non-breaking spaces.
+var QuoteStyles = map[string]QuoteStyle{
+	"en": {"“", "”", "‘", "’"},
+	"de": {"„", "“", "‚", "‘"},
+	"fr": {"«\u00a0", "\u00a0»", "‹\u00a0", "\u00a0›"},
+}
+
+// TypographerLocale selects the quote style from QuoteStyles, falling back
+// to English for unknown locales.
+var TypographerLocale = "en"
+
+var typographerReplacer = strings.NewReplacer(
+	EMDASHDELIMITER, "—",
+	ENDASHDELIMITER, "–",
+	ELLIPSISDELIMITER, "…",
+)
+
+// Typographer replaces straight quotes, dashes and ellipses in plain text
+// with their typographic counterparts. Other nodes, such as code, are left
+// untouched but still count as words when deciding whether a quote opens
+// or closes.
+func Typographer(nodes []Node) []Node {
+	elements := make([]*Element, 0, len(nodes))
+	for _, n := range nodes {
+		elements = append(elements, NewElement(n))
+	}
+	currentParser().typographer(elements)
+	return nodesOf(elements)
+}
+
+// typographer smartens the plain text of elements in place, so that they
+// keep the ranges of their source
+func (p *Parser) typographer(elements []*Element) {
+	var previous rune
+	for _, e := range elements {
+		switch v := e.Node.(type) {
+		case Plain:
+			text, last := smarten(string(v), previous, p.options.TypographerLocale)
+			e.Node = Plain(text)
+			previous = last
+		case Hyperlink, WikiLink:
+			p.typographer(e.Children)
+			previous = 'a'
+		default:
+			previous = 'a'
+		}
+	}
+}
+
+func smarten(text string, previous rune, locale string) (string, rune) {
+	style, isS := QuoteStyles[locale]
+	if !isS {
+		style = QuoteStyles["en"]
+	}
+	text = typographerReplacer.Replace(text)
+
+	builder := new(strings.Builder)
+	runes := []rune(text)
+	for i, r := range runes {
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch r {
+		case '"':
+			if opensQuote(previous) {
+				builder.WriteString(style.DoubleOpen)
+				previous = '“'
+			} else {
+				builder.WriteString(style.DoubleClose)
+				previous = '”'
+			}
+		case '\'':
+			// apostrophes inside words, as in "l'homme", are the same in
+			// every locale
+			if isWordRune(previous) && isWordRune(next) {
+				builder.WriteRune('’')
+				previous = '’'
+			} else if opensQuote(previous) {
+				builder.WriteString(style.SingleOpen)
+				previous = '‘'
+			} else {
+				builder.WriteString(style.SingleClose)
+				previous = '’'
+			}
+		default:
+			builder.WriteRune(r)
+			previous = r
+		}
+	}
+	return builder.String(), previous
+}
+
+func opensQuote(previous rune) bool {
+	return previous == 0 || unicode.IsSpace(previous) || strings.ContainsRune("([{-–—“‘", previous)
+}
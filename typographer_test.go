@@ -0,0 +1,139 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTypographer(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		input    []Node
+		expected []Node
+	}{
+		{
+			name:     "double quotes",
+			input:    []Node{Plain(`he said "hello" twice`)},
+			expected: []Node{Plain("he said “hello” twice")},
+		},
+		{
+			name:     "single quotes and apostrophes",
+			input:    []Node{Plain(`'tis the team's 'best' idea`)},
+			expected: []Node{Plain("‘tis the team’s ‘best’ idea")},
+		},
+		{
+			name:     "nested quotes",
+			input:    []Node{Plain(`"'quoted'"`)},
+			expected: []Node{Plain("“‘quoted’”")},
+		},
+		{
+			name:     "quote after parenthesis",
+			input:    []Node{Plain(`("aside")`)},
+			expected: []Node{Plain("(“aside”)")},
+		},
+		{
+			name:     "dashes and ellipsis",
+			input:    []Node{Plain("pages 1--5 --- wait...")},
+			expected: []Node{Plain("pages 1–5 — wait…")},
+		},
+		{
+			name:     "quotes around other nodes",
+			input:    []Node{Plain(`"`), Bold("bold"), Plain(`" text`)},
+			expected: []Node{Plain("“"), Bold("bold"), Plain("” text")},
+		},
+		{
+			name:     "code is untouched",
+			input:    []Node{Plain("use "), InlineCode(`"--" ...`), Plain(" here")},
+			expected: []Node{Plain("use "), InlineCode(`"--" ...`), Plain(" here")},
+		},
+		{
+			name:     "link content",
+			input:    []Node{Hyperlink{Content: []Node{Plain(`"link"`)}, Link: "a.com"}},
+			expected: []Node{Hyperlink{Content: []Node{Plain("“link”")}, Link: "a.com"}},
+		},
+		{
+			name:     "german quotes",
+			locale:   "de",
+			input:    []Node{Plain(`"Hallo" und 'hi'`)},
+			expected: []Node{Plain("„Hallo“ und ‚hi‘")},
+		},
+		{
+			name:     "french quotes",
+			locale:   "fr",
+			input:    []Node{Plain(`"Bonjour"`)},
+			expected: []Node{Plain("«\u00a0Bonjour\u00a0»")},
+		},
+		{
+			name:     "french apostrophe",
+			locale:   "fr",
+			input:    []Node{Plain(`l'homme dit 'oui'`)},
+			expected: []Node{Plain("l’homme dit ‹\u00a0oui\u00a0›")},
+		},
+		{
+			name:     "german apostrophe",
+			locale:   "de",
+			input:    []Node{Plain(`c'est 'gut'`)},
+			expected: []Node{Plain("c’est ‚gut‘")},
+		},
+		{
+			name:     "unknown locale falls back to english",
+			locale:   "xx",
+			input:    []Node{Plain(`"hi"`)},
+			expected: []Node{Plain("“hi”")},
+		},
+	}
+
+	defer func() { TypographerLocale = "en" }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			TypographerLocale = tt.locale
+			if len(tt.locale) == 0 {
+				TypographerLocale = "en"
+			}
+			got := Typographer(tt.input)
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("Typographer(%v)\n  got:      %v\n  expected: %v\n  Diff:      %s", tt.input, got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestTypographerExtension(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions Extension
+		input      string
+		expected   string
+	}{
+		{
+			name:     "disabled",
+			input:    `"quote" -- `,
+			expected: `<div><p>"quote" --</p></div>`,
+		},
+		{
+			name:       "enabled",
+			extensions: EXTTYPOGRAPHER,
+			input:      `"quote" -- it's ` + "`code \"--\"`",
+			expected:   "<div><p>“quote” – it’s <code>code \"--\"</code></p></div>",
+		},
+		{
+			name:       "code block untouched",
+			extensions: EXTTYPOGRAPHER,
+			input:      "```\n\"a\" -- b\n```",
+			expected:   "<div><pre><code>\n\"a\" -- b\n</code></pre></div>",
+		},
+	}
+
+	defer func() { Extensions = 0 }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Extensions = tt.extensions
+			got := MarkdownToHTML(tt.input).HTMLRender()
+			if got != tt.expected {
+				t.Errorf("MarkdownToHTML(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
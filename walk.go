@@ -0,0 +1,101 @@
+package markdownrenderer
+
+// WalkStatus tells Walk how to go on after visiting an element
+type WalkStatus int
+
+const (
+	WALKCONTINUE WalkStatus = iota
+	WALKSKIPCHILDREN
+	WALKSTOP
+)
+
+// Visitor is called by Walk when entering an element, before its children,
+// and when exiting it, after them. Skipping children only matters on
+// entering; elements whose children are skipped are still exited.
+type Visitor interface {
+	Enter(element *Element) WalkStatus
+	Exit(element *Element) WalkStatus
+}
+
+// VisitorFuncs adapts a pair of functions to the Visitor interface. Either
+// function may be nil.
+type VisitorFuncs struct {
+	OnEnter func(element *Element) WalkStatus
+	OnExit  func(element *Element) WalkStatus
+}
+
+func (v VisitorFuncs) Enter(element *Element) WalkStatus {
+	if v.OnEnter == nil {
+		return WALKCONTINUE
+	}
+	return v.OnEnter(element)
+}
+
+func (v VisitorFuncs) Exit(element *Element) WalkStatus {
+	if v.OnExit == nil {
+		return WALKCONTINUE
+	}
+	return v.OnExit(element)
+}
+
+// Walk visits element and its descendants depth first, in document order.
+// It returns WALKSTOP when the visitor stopped the walk early.
+func Walk(element *Element, visitor Visitor) WalkStatus {
+	status := visitor.Enter(element)
+	if status == WALKSTOP {
+		return WALKSTOP
+	}
+	if status != WALKSKIPCHILDREN {
+		for _, child := range element.Children {
+			if Walk(child, visitor) == WALKSTOP {
+				return WALKSTOP
+			}
+		}
+	}
+	if visitor.Exit(element) == WALKSTOP {
+		return WALKSTOP
+	}
+
+	return WALKCONTINUE
+}
+
+// FindAll returns the nodes of type T within root, root included, in
+// document order
+func FindAll[T Node](root *Element) []T {
+	found := []T{}
+	Walk(root, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+		if node, isT := match[T](e); isT {
+			found = append(found, node)
+		}
+		return WALKCONTINUE
+	}})
+
+	return found
+}
+
+// Find returns the first node of type T within root, root included
+func Find[T Node](root *Element) (T, bool) {
+	var found T
+	isFound := false
+	Walk(root, VisitorFuncs{OnEnter: func(e *Element) WalkStatus {
+		if node, isT := match[T](e); isT {
+			found = node
+			isFound = true
+			return WALKSTOP
+		}
+		return WALKCONTINUE
+	}})
+
+	return found, isFound
+}
+
+// match returns the node of e when it is a T, only then building it from the
+// tree, so that searching does not rebuild every subtree
+func match[T Node](e *Element) (T, bool) {
+	if _, isT := e.Node.(T); !isT {
+		var zero T
+		return zero, false
+	}
+	node, isT := e.Build().(T)
+	return node, isT
+}
@@ -0,0 +1,109 @@
+package markdownrenderer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWalk(t *testing.T) {
+	root := NewElement(Document{
+		Header{Content: []Node{Plain("Title")}, Level: 1},
+		Paragraph{Content: []Node{Plain("a "), Bold("b")}},
+		Quote{Plain("q")},
+	})
+	tests := []struct {
+		name           string
+		enter          func(e *Element) WalkStatus
+		expected       []string
+		expectedStatus WalkStatus
+	}{
+		{
+			name:           "full walk",
+			expected:       []string{"+document", "+header", "+plain", "-plain", "-header", "+paragraph", "+plain", "-plain", "+bold", "-bold", "-paragraph", "+quote", "+plain", "-plain", "-quote", "-document"},
+			expectedStatus: WALKCONTINUE,
+		},
+		{
+			name: "skip children",
+			enter: func(e *Element) WalkStatus {
+				if e.Kind() == PARAGRAPHNODE {
+					return WALKSKIPCHILDREN
+				}
+				return WALKCONTINUE
+			},
+			expected:       []string{"+document", "+header", "+plain", "-plain", "-header", "+paragraph", "-paragraph", "+quote", "+plain", "-plain", "-quote", "-document"},
+			expectedStatus: WALKCONTINUE,
+		},
+		{
+			name: "stop",
+			enter: func(e *Element) WalkStatus {
+				if e.Kind() == BOLDNODE {
+					return WALKSTOP
+				}
+				return WALKCONTINUE
+			},
+			expected:       []string{"+document", "+header", "+plain", "-plain", "-header", "+paragraph", "+plain", "-plain", "+bold"},
+			expectedStatus: WALKSTOP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			visitor := VisitorFuncs{
+				OnEnter: func(e *Element) WalkStatus {
+					got = append(got, "+"+string(e.Kind()))
+					if tt.enter != nil {
+						return tt.enter(e)
+					}
+					return WALKCONTINUE
+				},
+				OnExit: func(e *Element) WalkStatus {
+					got = append(got, "-"+string(e.Kind()))
+					return WALKCONTINUE
+				},
+			}
+			status := Walk(root, visitor)
+			if status != tt.expectedStatus {
+				t.Errorf("Walk() = %v, expected %v", status, tt.expectedStatus)
+			}
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("Walk() visits\n  got:      %v\n  expected: %v\n  Diff:     %s", got, tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	root := Parse("[a](a.com) and **[b](b.com)**\n\n> [!NOTE] see [c](c.com)\n> ![d](d.png)")
+	links := FindAll[Hyperlink](root)
+	expected := []Hyperlink{
+		{Content: []Node{Plain("a")}, Link: "a.com"},
+		{Content: []Node{Plain("b")}, Link: "b.com"},
+		{Content: []Node{Plain("c")}, Link: "c.com"},
+	}
+	if diff := cmp.Diff(links, expected); diff != "" {
+		t.Errorf("FindAll[Hyperlink]()\n  got:      %v\n  expected: %v\n  Diff:     %s", links, expected, diff)
+	}
+	if headers := FindAll[Header](root); len(headers) != 0 {
+		t.Errorf("FindAll[Header]() = %v, expected none", headers)
+	}
+
+	image, isFound := Find[Image](root)
+	if !isFound || image.Path != "d.png" {
+		t.Errorf("Find[Image]() = %v, %v, expected the image d.png", image, isFound)
+	}
+
+	cells := FindAll[TableItem](Parse("| A | B |\n| --- | --- |\n| 1 | 2 |"))
+	expectedCells := []TableItem{{Plain("A")}, {Plain("B")}, {Plain("1")}, {Plain("2")}}
+	if diff := cmp.Diff(cells, expectedCells); diff != "" {
+		t.Errorf("FindAll[TableItem]()\n  got:      %v\n  expected: %v\n  Diff:     %s", cells, expectedCells, diff)
+	}
+
+	// found nodes reflect the changes made to the tree
+	link := root.Children[0].Children[0]
+	link.Children[0].Replace(Plain("changed"))
+	changed, _ := Find[Hyperlink](root)
+	if diff := cmp.Diff(changed.Content, []Node{Plain("changed")}); diff != "" {
+		t.Errorf("Find[Hyperlink]() after change\n  got:      %v\n  Diff:     %s", changed, diff)
+	}
+}
@@ -0,0 +1,27 @@
+package markdownrenderer
+
+import "net/url"
+
+// WikiLinkResolver maps the page name of a wiki link to its URL and reports
+// whether the target page exists.
+type WikiLinkResolver interface {
+	Resolve(page string) (string, bool)
+}
+
+// WikiLinkResolverFunc adapts a function to the WikiLinkResolver interface.
+type WikiLinkResolverFunc func(page string) (string, bool)
+
+func (f WikiLinkResolverFunc) Resolve(page string) (string, bool) {
+	return f(page)
+}
+
+// WikiResolver resolves wiki links during rendering. When nil, links point
+// to the escaped page name and are assumed to exist.
+var WikiResolver WikiLinkResolver
+
+func resolveWikiLink(resolver WikiLinkResolver, page string) (string, bool) {
+	if resolver == nil {
+		return url.PathEscape(page), true
+	}
+	return resolver.Resolve(page)
+}
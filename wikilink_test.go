@@ -0,0 +1,49 @@
+package markdownrenderer
+
+import "testing"
+
+func TestWikiLinkRender(t *testing.T) {
+	pages := map[string]string{"Home": "/wiki/home"}
+	resolver := WikiLinkResolverFunc(func(page string) (string, bool) {
+		if link, exists := pages[page]; exists {
+			return link, true
+		}
+		return "/wiki/new?title=" + page, false
+	})
+
+	tests := []struct {
+		name     string
+		resolver WikiLinkResolver
+		input    WikiLink
+		expected string
+	}{
+		{
+			name:     "default resolver",
+			input:    WikiLink{Content: []Node{Plain("Page Name")}, Page: "Page Name"},
+			expected: "<a href='Page%20Name'>Page Name</a>",
+		},
+		{
+			name:     "existing page",
+			resolver: resolver,
+			input:    WikiLink{Content: []Node{Plain("home")}, Page: "Home"},
+			expected: "<a href='/wiki/home'>home</a>",
+		},
+		{
+			name:     "missing page",
+			resolver: resolver,
+			input:    WikiLink{Content: []Node{Plain("Draft")}, Page: "Draft"},
+			expected: "<a href='/wiki/new?title=Draft' class='new-page'>Draft</a>",
+		},
+	}
+
+	defer func() { WikiResolver = nil }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			WikiResolver = tt.resolver
+			got := tt.input.ToHTML().HTMLRender()
+			if got != tt.expected {
+				t.Errorf("WikiLink(%q).ToHTML().HTMLRender() = %q, expected %q", tt.input.Page, got, tt.expected)
+			}
+		})
+	}
+}